@@ -0,0 +1,109 @@
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// channelLink is a link bookmarked in every new channel.
+type channelLink struct {
+	Title string `yaml:"title"`
+	Url   string `yaml:"url"`
+}
+
+// videoCallTitle is the video call's bookmark title.
+const videoCallTitle = "Video Call"
+
+// channelLinks are the links to bookmark: the video call, then the
+// configured ones.
+func channelLinks() []channelLink {
+	links := []channelLink{{Title: videoCallTitle, Url: config.Call.Url}}
+	return append(links, config.Links...)
+}
+
+// syncBookmarks bookmarks the channel links. Bookmarks are matched by
+// title, so on re-runs changed links are edited rather than added again, and
+// links the janitor bookmarked that aren't configured anymore are removed.
+// Bookmarks people added are left alone.
+func syncBookmarks(ctx context.Context, w io.Writer, channelId string, botUserId string) error {
+	var list_resp client.BookmarksListResponse
+	_, err := Execute(ctx, client.BookmarksListRequest{ChannelId: channelId}, &list_resp)
+	if isSlackError(err) {
+		// Adding them anyway could duplicate them.
+		log.Printf("Can't list bookmarks, not adding any:\n%v", err)
+		fmt.Fprintf(w, "Couldn't bookmark the channel links\n")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	existing := map[string]client.Bookmark{}
+	var stale []client.Bookmark
+	wanted := map[string]bool{}
+	for _, link := range channelLinks() {
+		wanted[link.Title] = true
+	}
+	for _, bookmark := range list_resp.Bookmarks {
+		if _, dup := existing[bookmark.Title]; wanted[bookmark.Title] && !dup {
+			existing[bookmark.Title] = bookmark
+		} else if bookmark.LastUpdatedByUserId == botUserId {
+			stale = append(stale, bookmark)
+		}
+	}
+
+	added, edited := 0, 0
+	for _, link := range channelLinks() {
+		bookmark, found := existing[link.Title]
+		var bookmark_resp client.BookmarkResponse
+		switch {
+		case !found:
+			_, err = Execute(ctx, client.BookmarksAddRequest{
+				ChannelId: channelId,
+				Title:     link.Title,
+				Type:      client.BookmarkLink,
+				Link:      link.Url,
+			}, &bookmark_resp)
+			if err == nil {
+				added++
+			}
+		case bookmark.Link != link.Url:
+			_, err = Execute(ctx, client.BookmarksEditRequest{
+				ChannelId:  channelId,
+				BookmarkId: bookmark.Id,
+				Link:       link.Url,
+			}, &bookmark_resp)
+			if err == nil {
+				edited++
+			}
+		default:
+			continue
+		}
+		if isSlackError(err) {
+			log.Printf("Bookmarking %s failed, ignoring:\n%v", link.Title, err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	removed := 0
+	for _, bookmark := range stale {
+		var remove_resp client.GenericResponse
+		_, err := Execute(ctx, client.BookmarksRemoveRequest{
+			ChannelId:  channelId,
+			BookmarkId: bookmark.Id,
+		}, &remove_resp)
+		if isSlackError(err) {
+			log.Printf("Removing bookmark %s failed, ignoring:\n%v", bookmark.Title, err)
+		} else if err != nil {
+			return err
+		} else {
+			removed++
+		}
+	}
+	fmt.Fprintf(w, "Bookmarks: %d added, %d edited, %d removed\n", added, edited, removed)
+	return nil
+}
@@ -0,0 +1,292 @@
+package janitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CadenceConfig is how often the rotation gets a new channel. Every is one
+// of:
+//   - "daily".
+//   - "weekly": on Weekdays.
+//   - "biweekly": on Weekdays every other week, counting from the week of
+//     Start.
+//   - "monthly": on the Week'th of Weekdays in the month, e.g. 1 for the
+//     first Monday, or -1 for the last.
+//   - "cron": on the days matching Cron, e.g. "0 8 * * 2,4". Only the day
+//     fields are used, the time comes from cron.yaml.
+type CadenceConfig struct {
+	Every    string   `yaml:"every"`
+	Weekdays []string `yaml:"weekdays"`
+	// Start is a day in the first week of a biweekly cadence, e.g.
+	// "2026-10-01".
+	Start string `yaml:"start"`
+	Week  int    `yaml:"week"`
+	Cron  string `yaml:"cron"`
+
+	// on reports whether a day has a channel. nil is every day.
+	on func(day time.Time) bool
+	// interval, if set, is the days from one channel to the next.
+	interval int
+}
+
+// maxCadenceDays is how far to look for the previous or next channel, so
+// e.g. a cron on February 29th is still found.
+const maxCadenceDays = 4*366 + 1
+
+var weekdayNames = map[string]time.Weekday{}
+
+func init() {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		weekdayNames[name] = day
+		weekdayNames[name[:3]] = day
+	}
+}
+
+// parseWeekdays parses names like "tuesday" or "tue".
+func parseWeekdays(names []string) (map[time.Weekday]bool, error) {
+	weekdays := map[time.Weekday]bool{}
+	for _, name := range names {
+		day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("cadence.weekdays: unknown weekday %q", name)
+		}
+		weekdays[day] = true
+	}
+	return weekdays, nil
+}
+
+// compile checks the cadence, and sets on and interval.
+func (c *CadenceConfig) compile(loc *time.Location) error {
+	weekdays, err := parseWeekdays(c.Weekdays)
+	if err != nil {
+		return err
+	}
+	onWeekdays := func(day time.Time) bool { return weekdays[day.Weekday()] }
+	needsWeekdays := func() error {
+		if len(weekdays) == 0 {
+			return fmt.Errorf("cadence.weekdays is required for a %s cadence", c.Every)
+		}
+		return nil
+	}
+
+	switch c.Every {
+	case "daily":
+		c.interval = 1
+	case "weekly":
+		if err := needsWeekdays(); err != nil {
+			return err
+		}
+		c.on = onWeekdays
+	case "biweekly":
+		if err := needsWeekdays(); err != nil {
+			return err
+		}
+		start, err := time.ParseInLocation("2006-01-02", c.Start, loc)
+		if err != nil {
+			return fmt.Errorf("cadence.start %q isn't a date like 2026-10-01", c.Start)
+		}
+		firstMonday := startOfWeek(start)
+		c.on = func(day time.Time) bool {
+			weeks := daysBetween(firstMonday, startOfWeek(day)) / 7
+			return onWeekdays(day) && weeks%2 == 0
+		}
+	case "monthly":
+		if err := needsWeekdays(); err != nil {
+			return err
+		}
+		week := c.Week
+		if week == 0 || week < -1 || week > 5 {
+			return fmt.Errorf("cadence.week must be 1 to 5, or -1 for the last week")
+		}
+		c.on = func(day time.Time) bool {
+			if !onWeekdays(day) {
+				return false
+			}
+			if week == -1 {
+				return day.AddDate(0, 0, 7).Month() != day.Month()
+			}
+			return (day.Day()-1)/7+1 == week
+		}
+	case "cron":
+		spec, err := parseCron(c.Cron)
+		if err != nil {
+			return fmt.Errorf("cadence.cron: %w", err)
+		}
+		c.on = spec.matches
+	default:
+		return fmt.Errorf("unknown cadence %q, use daily, weekly, biweekly, monthly or cron", c.Every)
+	}
+
+	if _, found := c.next(time.Date(2026, time.January, 1, 0, 0, 0, 0, loc)); !found {
+		return fmt.Errorf("the cadence never has a channel")
+	}
+	return nil
+}
+
+// On reports whether the rotation has a channel on t's day.
+func (c *CadenceConfig) On(t time.Time) bool {
+	return c.on == nil || c.on(t)
+}
+
+// Previous is the day of the channel before the one on t's day.
+func (c *CadenceConfig) Previous(t time.Time) (time.Time, bool) {
+	if c.interval > 0 {
+		return t.AddDate(0, 0, -c.interval), true
+	}
+	for i := 1; i <= maxCadenceDays; i++ {
+		if day := t.AddDate(0, 0, -i); c.On(day) {
+			return day, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// next is the first day with a channel after t's day.
+func (c *CadenceConfig) next(t time.Time) (time.Time, bool) {
+	if c.interval > 0 {
+		return t.AddDate(0, 0, c.interval), true
+	}
+	for i := 1; i <= maxCadenceDays; i++ {
+		if day := t.AddDate(0, 0, i); c.On(day) {
+			return day, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// startOfWeek is midnight on the Monday of t's week.
+func startOfWeek(t time.Time) time.Time {
+	year, month, day := t.Date()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween counts the days from a to b, both at midnight, ignoring DST.
+func daysBetween(a time.Time, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(
+		time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// cronSpec is the day fields of a cron expression.
+type cronSpec struct {
+	daysOfMonth, months, weekdays map[int]bool
+	// Cron matches either day field when both are restricted.
+	anyDayOfMonth, anyWeekday bool
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// parseCron parses a standard five field cron expression:
+// minute, hour, day of month, month and day of week.
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%q should have 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+	weekdays := map[string]int{}
+	for name, day := range weekdayNames {
+		weekdays[name] = int(day)
+	}
+
+	var spec cronSpec
+	var err error
+	if _, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if _, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if spec.daysOfMonth, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if spec.months, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	// 7 is also Sunday.
+	if spec.weekdays, err = parseCronField(fields[4], 0, 7, weekdays); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if spec.weekdays[7] {
+		spec.weekdays[0] = true
+	}
+	spec.anyDayOfMonth = strings.HasPrefix(fields[2], "*")
+	spec.anyWeekday = strings.HasPrefix(fields[4], "*")
+	return &spec, nil
+}
+
+// parseCronField parses a comma separated list of "*", values and ranges,
+// each optionally with a "/step".
+func parseCronField(field string, min int, max int, names map[string]int) (map[int]bool, error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q isn't %d to %d", s, min, max)
+		}
+		return n, nil
+	}
+
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		first, last := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if first, err = value(bounds[0]); err != nil {
+				return nil, err
+			}
+			last = first
+			if len(bounds) == 2 {
+				if last, err = value(bounds[1]); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				last = max
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for n := first; n <= last; n += step {
+			values[n] = true
+		}
+	}
+	return values, nil
+}
+
+func (s *cronSpec) matches(day time.Time) bool {
+	if !s.months[int(day.Month())] {
+		return false
+	}
+	dayOfMonth := s.daysOfMonth[day.Day()]
+	weekday := s.weekdays[int(day.Weekday())]
+	switch {
+	case s.anyDayOfMonth && s.anyWeekday:
+		return true
+	case s.anyDayOfMonth:
+		return weekday
+	case s.anyWeekday:
+		return dayOfMonth
+	}
+	return dayOfMonth || weekday
+}
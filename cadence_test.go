@@ -0,0 +1,103 @@
+package janitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseCadence(t *testing.T, text string) *Config {
+	cfg, err := parseConfig([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestCadences(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		// day is on the cadence, previous is the channel before it.
+		day, previous string
+		// off isn't on the cadence.
+		off string
+	}{
+		{"daily", "{every: daily}", "2026-10-06", "2026-10-05", ""},
+		{"weekly", "{every: weekly, weekdays: [tue, Thursday]}", "2026-10-06", "2026-10-01", "2026-10-07"},
+		{"biweekly", "{every: biweekly, weekdays: [thursday], start: \"2026-09-28\"}",
+			"2026-10-15", "2026-10-01", "2026-10-08"},
+		{"biweekly over the new year", "{every: biweekly, weekdays: [thursday], start: \"2026-10-01\"}",
+			"2027-01-07", "2026-12-24", "2026-12-31"},
+		{"first monday", "{every: monthly, weekdays: [monday], week: 1}", "2026-11-02", "2026-10-05", "2026-10-12"},
+		{"last friday", "{every: monthly, weekdays: [friday], week: -1}", "2026-10-30", "2026-09-25", "2026-10-23"},
+		{"cron weekdays", "{every: cron, cron: \"0 8 * * tue,thu\"}", "2026-10-06", "2026-10-01", "2026-10-05"},
+		{"cron days of month", "{every: cron, cron: \"0 8 1,15 */3 *\"}", "2026-10-01", "2026-07-15", "2026-11-01"},
+		// Either day field matches when both are set.
+		{"cron both", "{every: cron, cron: \"0 8 13 * 5\"}", "2026-10-13", "2026-10-09", "2026-10-14"},
+		{"cron sunday", "{every: cron, cron: \"0 8 * * 7\"}", "2026-10-11", "2026-10-04", "2026-10-12"},
+		{"leap day", "{every: cron, cron: \"0 8 29 2 *\"}", "2028-02-29", "2024-02-29", "2027-02-28"},
+	} {
+		cfg := parseCadence(t, "cadence: "+test.config)
+		day := func(s string) time.Time {
+			parsed, err := time.ParseInLocation("2006-01-02", s, cfg.Location())
+			if err != nil {
+				t.Fatal(err)
+			}
+			// During the morning run.
+			return parsed.Add(8 * time.Hour)
+		}
+
+		if !cfg.Cadence.On(day(test.day)) {
+			t.Errorf("%s: expected a channel on %s", test.name, test.day)
+		}
+		previous, found := cfg.Cadence.Previous(day(test.day))
+		if !found || previous.Format("2006-01-02") != test.previous {
+			t.Errorf("%s: expected the previous channel on %s, got %v", test.name, test.previous, previous)
+		}
+		if len(test.off) > 0 && cfg.Cadence.On(day(test.off)) {
+			t.Errorf("%s: expected no channel on %s", test.name, test.off)
+		}
+	}
+}
+
+// A weekly cadence has no channel on the days in between, even though the
+// janitor runs every day.
+func TestWeeklyCadenceSkipsDaysBetween(t *testing.T) {
+	cfg := parseCadence(t, "cadence: {every: weekly, weekdays: [tuesday]}")
+	tuesday := time.Date(2026, 10, 6, 8, 0, 0, 0, cfg.Location())
+	for i := 1; i < 7; i++ {
+		if day := tuesday.AddDate(0, 0, i); cfg.Cadence.On(day) {
+			t.Errorf("Expected no channel on %s", day.Format("Mon 2006-01-02"))
+		}
+	}
+	if next := tuesday.AddDate(0, 0, 7); !cfg.Cadence.On(next) {
+		t.Errorf("Expected a channel on %s", next.Format("Mon 2006-01-02"))
+	}
+}
+
+func TestInvalidCadences(t *testing.T) {
+	for _, test := range []struct {
+		config  string
+		problem string
+	}{
+		{"{every: hourly}", "unknown cadence"},
+		{"{every: weekly, weekdays: [someday]}", "unknown weekday"},
+		{"{every: weekly}", "weekdays is required"},
+		{"{every: biweekly}", "weekdays is required"},
+		{"{every: biweekly, weekdays: [thu]}", "cadence.start"},
+		{"{every: monthly, weekdays: [mon], week: 6}", "cadence.week"},
+		{"{every: monthly, weekdays: [mon]}", "cadence.week"},
+		{"{every: cron, cron: \"0 8 * *\"}", "5 fields"},
+		{"{every: cron, cron: \"0 8 * * 8\"}", "day of week"},
+		{"{every: cron, cron: \"0 25 * * *\"}", "hour"},
+		{"{every: cron, cron: \"0 8 5-1 * *\"}", "invalid range"},
+		{"{every: cron, cron: \"0 8 */0 * *\"}", "invalid step"},
+		{"{every: cron, cron: \"0 8 31 feb *\"}", "never has a channel"},
+	} {
+		_, err := parseConfig([]byte("cadence: " + test.config))
+		if err == nil || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("Expected %q to fail with %q, got: %v", test.config, test.problem, err)
+		}
+	}
+}
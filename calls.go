@@ -0,0 +1,211 @@
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// messageCallId returns the ID of the Call in the message's call block, if
+// the janitor posted it, or "". Slack can't look up Calls by their external
+// ID, so later runs find them this way to update or end them.
+func messageCallId(msg client.Message, botUserId string) string {
+	if msg.User != botUserId {
+		return ""
+	}
+	for _, block := range msg.Blocks {
+		if call, ok := block.(client.CallBlock); ok {
+			return call.CallId
+		}
+	}
+	return ""
+}
+
+// channelCallId returns the ID of the latest Call the janitor posted to the
+// channel, or "".
+func channelCallId(ctx context.Context, channel *client.Channel, botUserId string) (string, error) {
+	callId := ""
+	var history_resp client.MessagesResponse
+	err := Paginate(ctx, client.ConversationsHistoryRequest{ChannelId: channel.Id},
+		&history_resp, func() error {
+			// History is newest first.
+			for _, msg := range history_resp.Messages {
+				if callId = messageCallId(msg, botUserId); len(callId) > 0 {
+					return client.ErrStopPagination
+				}
+			}
+			return nil
+		})
+	if isSlackError(err) {
+		log.Printf("Can't read the history of #%s, ignoring:\n%v", channel.Name, err)
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return callId, nil
+}
+
+// configuredCall is today's Call in the named channel, as configured.
+func configuredCall(channelName string) client.Call {
+	return client.Call{
+		ExternalUniqueId:  channelName,
+		JoinUrl:           config.Call.Url,
+		ExternalDisplayId: config.Call.Id,
+		Title:             config.Call.Title,
+		StartTimeUnix:     todayCallStart().Unix(),
+	}
+}
+
+// callText is the text of the message posting the Call. It links the video
+// call, so scheduleAnnouncements can tell if a scheduled one is up to date.
+func callText(vcUrl string) string {
+	return "Join the Video Call: " + client.FormatLink(vcUrl)
+}
+
+// addCall adds today's Call in the named channel, as configured, and returns
+// its ID.
+func addCall(ctx context.Context, w io.Writer, channelName string) (string, error) {
+	var callResp client.CallResponse
+	if _, err := Execute(ctx, configuredCall(channelName), &callResp); err != nil {
+		return "", fmt.Errorf("Error adding the call: %w", err)
+	}
+	fmt.Fprintf(w, "Created call %s\n", callResp.Call.Id)
+	return callResp.Call.Id, nil
+}
+
+// postNewCall adds today's Call and posts it to the channel right away.
+func postNewCall(ctx context.Context, w io.Writer, channel *client.Channel) error {
+	callId, err := addCall(ctx, w, channel.Name)
+	if err != nil {
+		return err
+	}
+	var postResp client.PostMessageResponse
+	_, err = Execute(ctx,
+		client.PostMessageRequest{
+			ChannelId: channel.Id,
+			Text:      callText(config.Call.Url),
+			Blocks:    client.Blocks{client.CallBlock{CallId: callId}},
+		},
+		&postResp)
+	if err != nil {
+		return fmt.Errorf("Error posting the call: %w", err)
+	}
+	return nil
+}
+
+// updatePostedCall updates the channel's Call to match the config, if the
+// janitor already posted it. Returns whether it had.
+func updatePostedCall(ctx context.Context, w io.Writer, channel *client.Channel, botUserId string) (bool, error) {
+	callId, err := channelCallId(ctx, channel, botUserId)
+	if err != nil || len(callId) == 0 {
+		return false, err
+	}
+	fmt.Fprintf(w, "Call %s was already posted\n", callId)
+	return true, updateCall(ctx, w, callId, configuredCall(channel.Name))
+}
+
+// getCall calls calls.info. Returns nil, logging why, if Slack refuses.
+func getCall(ctx context.Context, callId string) (*client.Call, error) {
+	var callResp client.CallResponse
+	_, err := Execute(ctx, client.CallInfoRequest{Id: callId}, &callResp)
+	if isSlackError(err) {
+		log.Printf("Can't get call %s, ignoring:\n%v", callId, err)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &callResp.Call, nil
+}
+
+// showParticipants writes who joined the call, by the names people see in
+// Slack.
+func showParticipants(ctx context.Context, w io.Writer, call *client.Call) error {
+	names := make([]string, 0, len(call.Users))
+	for _, user := range call.Users {
+		switch {
+		case len(user.SlackId) > 0:
+			slackNames, err := userNames(ctx, []string{user.SlackId})
+			if err != nil {
+				return err
+			}
+			names = append(names, slackNames...)
+		case len(user.DisplayName) > 0:
+			names = append(names, user.DisplayName)
+		default:
+			names = append(names, user.ExternalId)
+		}
+	}
+	log.Printf("Call %s has %d participants: %v", call.Id, len(names), names)
+	fmt.Fprintf(w, "Call %s participants (%d): %s\n", call.Id, len(names), strings.Join(names, ", "))
+	return nil
+}
+
+// endChannelCall ends the channel's Call, found by summarizeChannel, if it's
+// still ongoing, so its block stops showing as ongoing.
+func endChannelCall(ctx context.Context, w io.Writer, channel *client.Channel, callId string) error {
+	if len(callId) == 0 {
+		log.Printf("No call in #%s", channel.Name)
+		return nil
+	}
+	call, err := getCall(ctx, callId)
+	if err != nil || call == nil {
+		return err
+	}
+	if err := showParticipants(ctx, w, call); err != nil {
+		return err
+	}
+	if call.DateEnd != 0 {
+		log.Printf("Call %s already ended", callId)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Ending call %s in #%s\n", callId, channel.Name)
+	var endResp client.GenericResponse
+	_, err = Execute(ctx, client.CallEnd{Id: callId}, &endResp)
+	if isSlackError(err) {
+		log.Printf("Ending call failed, ignoring:\n%v", err)
+		return nil
+	}
+	return err
+}
+
+// updateCall updates the Call's title and join URL if the configured ones
+// changed since it was posted.
+func updateCall(ctx context.Context, w io.Writer, callId string, configured client.Call) error {
+	call, err := getCall(ctx, callId)
+	if err != nil || call == nil {
+		return err
+	}
+	if err := showParticipants(ctx, w, call); err != nil {
+		return err
+	}
+	if call.DateEnd != 0 {
+		fmt.Fprintf(w, "Call %s already ended, not updating it\n", callId)
+		return nil
+	}
+
+	update := client.CallUpdateRequest{Id: callId}
+	if call.Title != configured.Title {
+		update.Title = configured.Title
+	}
+	if call.JoinUrl != configured.JoinUrl {
+		update.JoinUrl = configured.JoinUrl
+	}
+	if update == (client.CallUpdateRequest{Id: callId}) {
+		fmt.Fprintf(w, "Call %s is up to date\n", callId)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Updating call %s\n", callId)
+	var updateResp client.CallResponse
+	_, err = Execute(ctx, update, &updateResp)
+	if isSlackError(err) {
+		log.Printf("Updating call failed, ignoring:\n%v", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,933 @@
+// Block Kit layout blocks, see https://api.slack.com/reference/block-kit
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Block is a Block Kit layout block.
+type Block interface {
+	// BlockType returns the block's "type", e.g. "section".
+	BlockType() string
+	// Validate checks the block against Slack's limits.
+	Validate() error
+}
+
+// Element is a block element, e.g. a button, or a text or image in a
+// context block.
+type Element interface {
+	// ElementType returns the element's "type", e.g. "button".
+	ElementType() string
+	// Validate checks the element against Slack's limits.
+	Validate() error
+}
+
+// Limits from the Block Kit reference.
+const (
+	MaxMessageBlocks     = 50
+	MaxBlockIdLength     = 255
+	MaxSectionTextLength = 3000
+	MaxSectionFields     = 10
+	MaxFieldTextLength   = 2000
+	MaxHeaderTextLength  = 150
+	MaxContextElements   = 10
+	MaxActionsElements   = 25
+	MaxButtonTextLength  = 75
+	MaxActionIdLength    = 255
+	MaxUrlLength         = 3000
+	MaxAltTextLength     = 2000
+	MaxValueLength       = 2000
+	MaxSelectOptions     = 100
+	MaxOptionTextLength  = 75
+	MaxOptionValueLength = 150
+	MaxPlaceholderLength = 150
+)
+
+// BlockError describes a block or element breaking Slack's rules.
+type BlockError struct {
+	// Index is the position of the block in the message, or -1.
+	Index  int
+	Type   string
+	Reason string
+}
+
+func (e *BlockError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("Invalid %s: %s", e.Type, e.Reason)
+	}
+	return fmt.Sprintf("Invalid %s block at index %d: %s", e.Type, e.Index, e.Reason)
+}
+
+func invalid(kind string, format string, args ...interface{}) error {
+	return &BlockError{Index: -1, Type: kind, Reason: fmt.Sprintf(format, args...)}
+}
+
+// checkLength fails if s is longer than max characters, or is empty but
+// required.
+func checkLength(kind, field, s string, max int, required bool) error {
+	if required && len(s) == 0 {
+		return invalid(kind, "%s is required", field)
+	}
+	if n := utf8.RuneCountInString(s); n > max {
+		return invalid(kind, "%s is %d characters, more than %d", field, n, max)
+	}
+	return nil
+}
+
+// ValidateBlocks checks a message's blocks against Slack's limits.
+func ValidateBlocks(blocks []Block) error {
+	if len(blocks) > MaxMessageBlocks {
+		return &BlockError{Index: -1, Type: "message",
+			Reason: fmt.Sprintf("%d blocks, more than %d", len(blocks), MaxMessageBlocks)}
+	}
+	for i, block := range blocks {
+		if err := block.Validate(); err != nil {
+			if blockErr, ok := err.(*BlockError); ok {
+				reason := blockErr.Reason
+				if blockErr.Type != block.BlockType() {
+					// An element of the block.
+					reason = blockErr.Type + " " + reason
+				}
+				return &BlockError{Index: i, Type: block.BlockType(), Reason: reason}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// withType marshals v, adding its "type".
+func withType(blockType string, v interface{}) ([]byte, error) {
+	fields, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	typeField, _ := json.Marshal(blockType)
+	if string(fields) == "{}" {
+		return []byte(`{"type":` + string(typeField) + `}`), nil
+	}
+	return []byte(`{"type":` + string(typeField) + `,` + string(fields[1:])), nil
+}
+
+// Composition objects.
+
+// TextObject is a plain_text or mrkdwn text object. It's also a valid
+// element of context blocks.
+type TextObject struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+}
+
+// PlainText returns a plain_text object.
+func PlainText(text string) TextObject {
+	return TextObject{Type: "plain_text", Text: text}
+}
+
+// Markdown returns a mrkdwn text object.
+func Markdown(text string) TextObject {
+	return TextObject{Type: "mrkdwn", Text: text}
+}
+
+func (t TextObject) ElementType() string {
+	return t.Type
+}
+
+func (t TextObject) Validate() error {
+	if t.Type != "plain_text" && t.Type != "mrkdwn" {
+		return invalid("text", "unknown type %q", t.Type)
+	}
+	return checkLength(t.Type, "text", t.Text, MaxSectionTextLength, true)
+}
+
+// validatePlainText checks a text object that must be plain_text.
+func validatePlainText(kind string, field string, t TextObject, max int) error {
+	if t.Type != "plain_text" {
+		return invalid(kind, "%s must be plain_text, not %q", field, t.Type)
+	}
+	return checkLength(kind, field, t.Text, max, true)
+}
+
+// SelectOption is an option of a select menu.
+type SelectOption struct {
+	Text  TextObject `json:"text"`
+	Value string     `json:"value"`
+}
+
+func (o SelectOption) validate() error {
+	if err := validatePlainText("option", "text", o.Text, MaxOptionTextLength); err != nil {
+		return err
+	}
+	return checkLength("option", "value", o.Value, MaxOptionValueLength, true)
+}
+
+// Elements.
+
+// ImageElement is an image in a context block or section accessory.
+type ImageElement struct {
+	ImageUrl string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+func (e ImageElement) ElementType() string {
+	return "image"
+}
+
+func (e ImageElement) Validate() error {
+	if err := checkLength("image", "image_url", e.ImageUrl, MaxUrlLength, true); err != nil {
+		return err
+	}
+	return checkLength("image", "alt_text", e.AltText, MaxAltTextLength, true)
+}
+
+func (e ImageElement) MarshalJSON() ([]byte, error) {
+	type fields ImageElement
+	return withType(e.ElementType(), fields(e))
+}
+
+// ButtonElement is a button. Buttons with a Url open it in the browser.
+type ButtonElement struct {
+	ActionId string     `json:"action_id,omitempty"`
+	Text     TextObject `json:"text"`
+	Url      string     `json:"url,omitempty"`
+	Value    string     `json:"value,omitempty"`
+	// Style is "primary", "danger", or empty for the default.
+	Style string `json:"style,omitempty"`
+}
+
+// LinkButton returns a button opening url.
+func LinkButton(actionId string, text string, url string) ButtonElement {
+	return ButtonElement{ActionId: actionId, Text: PlainText(text), Url: url}
+}
+
+func (e ButtonElement) ElementType() string {
+	return "button"
+}
+
+func (e ButtonElement) Validate() error {
+	if err := validatePlainText("button", "text", e.Text, MaxButtonTextLength); err != nil {
+		return err
+	}
+	if err := checkLength("button", "action_id", e.ActionId, MaxActionIdLength, false); err != nil {
+		return err
+	}
+	if err := checkLength("button", "url", e.Url, MaxUrlLength, false); err != nil {
+		return err
+	}
+	if err := checkLength("button", "value", e.Value, MaxValueLength, false); err != nil {
+		return err
+	}
+	if e.Style != "" && e.Style != "primary" && e.Style != "danger" {
+		return invalid("button", "unknown style %q", e.Style)
+	}
+	return nil
+}
+
+func (e ButtonElement) MarshalJSON() ([]byte, error) {
+	type fields ButtonElement
+	return withType(e.ElementType(), fields(e))
+}
+
+// StaticSelectElement is a select menu with a fixed list of options.
+type StaticSelectElement struct {
+	ActionId      string         `json:"action_id,omitempty"`
+	Placeholder   *TextObject    `json:"placeholder,omitempty"`
+	Options       []SelectOption `json:"options"`
+	InitialOption *SelectOption  `json:"initial_option,omitempty"`
+}
+
+func (e StaticSelectElement) ElementType() string {
+	return "static_select"
+}
+
+func (e StaticSelectElement) Validate() error {
+	if err := checkLength("static_select", "action_id", e.ActionId, MaxActionIdLength, false); err != nil {
+		return err
+	}
+	if e.Placeholder != nil {
+		if err := validatePlainText("static_select", "placeholder", *e.Placeholder, MaxPlaceholderLength); err != nil {
+			return err
+		}
+	}
+	if len(e.Options) == 0 || len(e.Options) > MaxSelectOptions {
+		return invalid("static_select", "needs 1 to %d options, got %d", MaxSelectOptions, len(e.Options))
+	}
+	for _, option := range e.Options {
+		if err := option.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e StaticSelectElement) MarshalJSON() ([]byte, error) {
+	type fields StaticSelectElement
+	return withType(e.ElementType(), fields(e))
+}
+
+// UnknownElement holds an element of a type this package doesn't model,
+// as decoded from a message.
+type UnknownElement struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (e UnknownElement) ElementType() string {
+	return e.Type
+}
+
+func (e UnknownElement) Validate() error {
+	return nil
+}
+
+func (e UnknownElement) MarshalJSON() ([]byte, error) {
+	return e.Raw, nil
+}
+
+// decodeElement decodes an element by its "type".
+func decodeElement(raw json.RawMessage) (Element, error) {
+	var typed struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &typed); err != nil {
+		return nil, err
+	}
+
+	var element Element
+	var err error
+	switch typed.Type {
+	case "plain_text", "mrkdwn":
+		var e TextObject
+		err = json.Unmarshal(raw, &e)
+		element = e
+	case "image":
+		var e ImageElement
+		err = json.Unmarshal(raw, &e)
+		element = e
+	case "button":
+		var e ButtonElement
+		err = json.Unmarshal(raw, &e)
+		element = e
+	case "static_select":
+		var e StaticSelectElement
+		err = json.Unmarshal(raw, &e)
+		element = e
+	default:
+		element = UnknownElement{Type: typed.Type, Raw: raw}
+	}
+	return element, err
+}
+
+func decodeElements(raws []json.RawMessage) ([]Element, error) {
+	var elements []Element
+	for _, raw := range raws {
+		element, err := decodeElement(raw)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
+
+// Blocks.
+
+// SectionBlock shows text, or fields side by side, with an optional
+// accessory element such as a button or image.
+type SectionBlock struct {
+	BlockId   string       `json:"block_id,omitempty"`
+	Text      *TextObject  `json:"text,omitempty"`
+	Fields    []TextObject `json:"fields,omitempty"`
+	Accessory Element      `json:"accessory,omitempty"`
+}
+
+func (b SectionBlock) BlockType() string {
+	return "section"
+}
+
+func (b SectionBlock) Validate() error {
+	if err := checkLength("section", "block_id", b.BlockId, MaxBlockIdLength, false); err != nil {
+		return err
+	}
+	if b.Text == nil && len(b.Fields) == 0 {
+		return invalid("section", "needs text or fields")
+	}
+	if b.Text != nil {
+		if err := b.Text.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(b.Fields) > MaxSectionFields {
+		return invalid("section", "%d fields, more than %d", len(b.Fields), MaxSectionFields)
+	}
+	for _, field := range b.Fields {
+		if err := field.Validate(); err != nil {
+			return err
+		}
+		if err := checkLength("section", "field", field.Text, MaxFieldTextLength, true); err != nil {
+			return err
+		}
+	}
+	if b.Accessory != nil {
+		return b.Accessory.Validate()
+	}
+	return nil
+}
+
+func (b SectionBlock) MarshalJSON() ([]byte, error) {
+	type fields SectionBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+func (b *SectionBlock) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		BlockId   string          `json:"block_id"`
+		Text      *TextObject     `json:"text"`
+		Fields    []TextObject    `json:"fields"`
+		Accessory json.RawMessage `json:"accessory"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*b = SectionBlock{BlockId: fields.BlockId, Text: fields.Text, Fields: fields.Fields}
+	if len(fields.Accessory) > 0 {
+		accessory, err := decodeElement(fields.Accessory)
+		if err != nil {
+			return err
+		}
+		b.Accessory = accessory
+	}
+	return nil
+}
+
+// HeaderBlock shows large, bold plain text.
+type HeaderBlock struct {
+	BlockId string     `json:"block_id,omitempty"`
+	Text    TextObject `json:"text"`
+}
+
+func (b HeaderBlock) BlockType() string {
+	return "header"
+}
+
+func (b HeaderBlock) Validate() error {
+	if err := checkLength("header", "block_id", b.BlockId, MaxBlockIdLength, false); err != nil {
+		return err
+	}
+	return validatePlainText("header", "text", b.Text, MaxHeaderTextLength)
+}
+
+func (b HeaderBlock) MarshalJSON() ([]byte, error) {
+	type fields HeaderBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+// DividerBlock is a horizontal rule.
+type DividerBlock struct {
+	BlockId string `json:"block_id,omitempty"`
+}
+
+func (b DividerBlock) BlockType() string {
+	return "divider"
+}
+
+func (b DividerBlock) Validate() error {
+	return checkLength("divider", "block_id", b.BlockId, MaxBlockIdLength, false)
+}
+
+func (b DividerBlock) MarshalJSON() ([]byte, error) {
+	type fields DividerBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+// ContextBlock shows small text and images.
+type ContextBlock struct {
+	BlockId string `json:"block_id,omitempty"`
+	// Elements are TextObjects and ImageElements.
+	Elements []Element `json:"elements"`
+}
+
+func (b ContextBlock) BlockType() string {
+	return "context"
+}
+
+func (b ContextBlock) Validate() error {
+	if err := checkLength("context", "block_id", b.BlockId, MaxBlockIdLength, false); err != nil {
+		return err
+	}
+	if len(b.Elements) == 0 || len(b.Elements) > MaxContextElements {
+		return invalid("context", "needs 1 to %d elements, got %d", MaxContextElements, len(b.Elements))
+	}
+	for _, element := range b.Elements {
+		switch element.(type) {
+		case TextObject, ImageElement:
+		default:
+			return invalid("context", "can't contain %s elements", element.ElementType())
+		}
+		if err := element.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b ContextBlock) MarshalJSON() ([]byte, error) {
+	type fields ContextBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+func (b *ContextBlock) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		BlockId  string            `json:"block_id"`
+		Elements []json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	elements, err := decodeElements(fields.Elements)
+	*b = ContextBlock{BlockId: fields.BlockId, Elements: elements}
+	return err
+}
+
+// ImageBlock shows an image.
+type ImageBlock struct {
+	BlockId  string      `json:"block_id,omitempty"`
+	ImageUrl string      `json:"image_url"`
+	AltText  string      `json:"alt_text"`
+	Title    *TextObject `json:"title,omitempty"`
+}
+
+func (b ImageBlock) BlockType() string {
+	return "image"
+}
+
+func (b ImageBlock) Validate() error {
+	if err := checkLength("image", "block_id", b.BlockId, MaxBlockIdLength, false); err != nil {
+		return err
+	}
+	if err := (ImageElement{ImageUrl: b.ImageUrl, AltText: b.AltText}).Validate(); err != nil {
+		return err
+	}
+	if b.Title != nil {
+		return validatePlainText("image", "title", *b.Title, MaxAltTextLength)
+	}
+	return nil
+}
+
+func (b ImageBlock) MarshalJSON() ([]byte, error) {
+	type fields ImageBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+// ActionsBlock holds interactive elements, such as buttons and selects.
+type ActionsBlock struct {
+	BlockId  string    `json:"block_id,omitempty"`
+	Elements []Element `json:"elements"`
+}
+
+func (b ActionsBlock) BlockType() string {
+	return "actions"
+}
+
+func (b ActionsBlock) Validate() error {
+	if err := checkLength("actions", "block_id", b.BlockId, MaxBlockIdLength, false); err != nil {
+		return err
+	}
+	if len(b.Elements) == 0 || len(b.Elements) > MaxActionsElements {
+		return invalid("actions", "needs 1 to %d elements, got %d", MaxActionsElements, len(b.Elements))
+	}
+	for _, element := range b.Elements {
+		switch element.(type) {
+		case ButtonElement, StaticSelectElement, UnknownElement:
+		default:
+			return invalid("actions", "can't contain %s elements", element.ElementType())
+		}
+		if err := element.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b ActionsBlock) MarshalJSON() ([]byte, error) {
+	type fields ActionsBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+func (b *ActionsBlock) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		BlockId  string            `json:"block_id"`
+		Elements []json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	elements, err := decodeElements(fields.Elements)
+	*b = ActionsBlock{BlockId: fields.BlockId, Elements: elements}
+	return err
+}
+
+// RichTextElement is part of a rich_text block. Sections, lists, quotes
+// and preformatted text contain further Elements; text, link, user,
+// channel and emoji elements are the leaves.
+type RichTextElement struct {
+	Type string `json:"type"`
+
+	Text      string         `json:"text,omitempty"`
+	Url       string         `json:"url,omitempty"`
+	UserId    string         `json:"user_id,omitempty"`
+	ChannelId string         `json:"channel_id,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Style     *RichTextStyle `json:"style,omitempty"`
+
+	// ListStyle is "bullet" or "ordered", for rich_text_list.
+	ListStyle string `json:"-"`
+
+	Elements []RichTextElement `json:"elements,omitempty"`
+}
+
+// RichTextStyle styles a rich text leaf.
+type RichTextStyle struct {
+	Bold   bool `json:"bold,omitempty"`
+	Italic bool `json:"italic,omitempty"`
+	Strike bool `json:"strike,omitempty"`
+	Code   bool `json:"code,omitempty"`
+}
+
+// RichTextSection returns a paragraph of rich text.
+func RichTextSection(elements ...RichTextElement) RichTextElement {
+	return RichTextElement{Type: "rich_text_section", Elements: elements}
+}
+
+// RichTextList returns a "bullet" or "ordered" list of sections.
+func RichTextList(style string, sections ...RichTextElement) RichTextElement {
+	return RichTextElement{Type: "rich_text_list", ListStyle: style, Elements: sections}
+}
+
+// RichTextQuote returns a block quote.
+func RichTextQuote(elements ...RichTextElement) RichTextElement {
+	return RichTextElement{Type: "rich_text_quote", Elements: elements}
+}
+
+// RichTextPreformatted returns a code block.
+func RichTextPreformatted(elements ...RichTextElement) RichTextElement {
+	return RichTextElement{Type: "rich_text_preformatted", Elements: elements}
+}
+
+// RichTextText returns styled text. style may be nil.
+func RichTextText(text string, style *RichTextStyle) RichTextElement {
+	return RichTextElement{Type: "text", Text: text, Style: style}
+}
+
+// RichTextLink returns a link, shown as text, or the url if text is empty.
+func RichTextLink(url string, text string) RichTextElement {
+	return RichTextElement{Type: "link", Url: url, Text: text}
+}
+
+// RichTextUser returns a user mention.
+func RichTextUser(userId string) RichTextElement {
+	return RichTextElement{Type: "user", UserId: userId}
+}
+
+// RichTextEmoji returns an emoji, by name.
+func RichTextEmoji(name string) RichTextElement {
+	return RichTextElement{Type: "emoji", Name: name}
+}
+
+// rich_text_list uses "style" for the list style, a string rather than
+// a RichTextStyle.
+func (e RichTextElement) MarshalJSON() ([]byte, error) {
+	type fields RichTextElement
+	if e.Type != "rich_text_list" {
+		return json.Marshal(fields(e))
+	}
+	e.Style = nil
+	data, err := json.Marshal(fields(e))
+	if err != nil || len(e.ListStyle) == 0 {
+		return data, err
+	}
+	style, _ := json.Marshal(e.ListStyle)
+	return []byte(`{"style":` + string(style) + `,` + string(data[1:])), nil
+}
+
+func (e *RichTextElement) UnmarshalJSON(data []byte) error {
+	type fields RichTextElement
+	var f struct {
+		fields
+		Style json.RawMessage `json:"style"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*e = RichTextElement(f.fields)
+	if len(f.Style) == 0 {
+		return nil
+	}
+	if e.Type == "rich_text_list" {
+		return json.Unmarshal(f.Style, &e.ListStyle)
+	}
+	e.Style = &RichTextStyle{}
+	return json.Unmarshal(f.Style, e.Style)
+}
+
+func (e RichTextElement) validate() error {
+	switch e.Type {
+	case "rich_text_section", "rich_text_quote", "rich_text_preformatted":
+	case "rich_text_list":
+		if e.ListStyle != "bullet" && e.ListStyle != "ordered" {
+			return invalid("rich_text_list", "unknown style %q", e.ListStyle)
+		}
+		for _, section := range e.Elements {
+			if section.Type != "rich_text_section" {
+				return invalid("rich_text_list", "can only contain sections, not %s", section.Type)
+			}
+		}
+	case "text":
+		return checkLength("text", "text", e.Text, MaxSectionTextLength, true)
+	case "link":
+		return checkLength("link", "url", e.Url, MaxUrlLength, true)
+	case "user":
+		return checkLength("user", "user_id", e.UserId, MaxBlockIdLength, true)
+	case "channel":
+		return checkLength("channel", "channel_id", e.ChannelId, MaxBlockIdLength, true)
+	case "emoji":
+		return checkLength("emoji", "name", e.Name, MaxBlockIdLength, true)
+	default:
+		return invalid("rich_text", "unknown element type %q", e.Type)
+	}
+	for _, element := range e.Elements {
+		if err := element.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RichTextBlock shows formatted text, as produced by Slack's composer.
+type RichTextBlock struct {
+	BlockId string `json:"block_id,omitempty"`
+	// Elements are sections, lists, quotes and preformatted text.
+	Elements []RichTextElement `json:"elements"`
+}
+
+func (b RichTextBlock) BlockType() string {
+	return "rich_text"
+}
+
+func (b RichTextBlock) Validate() error {
+	if err := checkLength("rich_text", "block_id", b.BlockId, MaxBlockIdLength, false); err != nil {
+		return err
+	}
+	if len(b.Elements) == 0 {
+		return invalid("rich_text", "needs elements")
+	}
+	for _, element := range b.Elements {
+		switch element.Type {
+		case "rich_text_section", "rich_text_list", "rich_text_quote", "rich_text_preformatted":
+		default:
+			return invalid("rich_text", "can't contain %s at the top level", element.Type)
+		}
+		if err := element.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b RichTextBlock) MarshalJSON() ([]byte, error) {
+	type fields RichTextBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+// CallBlock shows a Call, added with calls.add.
+type CallBlock struct {
+	BlockId string `json:"block_id,omitempty"`
+	CallId  string `json:"call_id"`
+}
+
+func (b CallBlock) BlockType() string {
+	return "call"
+}
+
+func (b CallBlock) Validate() error {
+	if err := checkLength("call", "block_id", b.BlockId, MaxBlockIdLength, false); err != nil {
+		return err
+	}
+	return checkLength("call", "call_id", b.CallId, MaxBlockIdLength, true)
+}
+
+func (b CallBlock) MarshalJSON() ([]byte, error) {
+	type fields CallBlock
+	return withType(b.BlockType(), fields(b))
+}
+
+// UnknownBlock holds a block of a type this package doesn't model, as
+// decoded from a message.
+type UnknownBlock struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (b UnknownBlock) BlockType() string {
+	return b.Type
+}
+
+func (b UnknownBlock) Validate() error {
+	return nil
+}
+
+func (b UnknownBlock) MarshalJSON() ([]byte, error) {
+	return b.Raw, nil
+}
+
+// Blocks is a list of blocks, which can be decoded from JSON.
+type Blocks []Block
+
+func (blocks *Blocks) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	if raws == nil {
+		*blocks = nil
+		return nil
+	}
+
+	decoded := make(Blocks, 0, len(raws))
+	for _, raw := range raws {
+		var typed struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &typed); err != nil {
+			return err
+		}
+
+		var block Block
+		var err error
+		switch typed.Type {
+		case "section":
+			var b SectionBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		case "header":
+			var b HeaderBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		case "divider":
+			var b DividerBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		case "context":
+			var b ContextBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		case "image":
+			var b ImageBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		case "actions":
+			var b ActionsBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		case "rich_text":
+			var b RichTextBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		case "call":
+			var b CallBlock
+			err = json.Unmarshal(raw, &b)
+			block = b
+		default:
+			block = UnknownBlock{Type: typed.Type, Raw: raw}
+		}
+		if err != nil {
+			return fmt.Errorf("Error decoding %s block: %w", typed.Type, err)
+		}
+		decoded = append(decoded, block)
+	}
+	*blocks = decoded
+	return nil
+}
+
+// BlockBuilder builds a message's blocks.
+//
+//	blocks, err := client.NewBlockBuilder().
+//		Header("Game night").
+//		Markdown("Join us at *6:30pm*").
+//		Actions(client.LinkButton("join", "Join the call", url)).
+//		Build()
+type BlockBuilder struct {
+	blocks Blocks
+}
+
+func NewBlockBuilder() *BlockBuilder {
+	return &BlockBuilder{}
+}
+
+// Add appends any block.
+func (b *BlockBuilder) Add(block Block) *BlockBuilder {
+	b.blocks = append(b.blocks, block)
+	return b
+}
+
+// Header appends a header with plain text.
+func (b *BlockBuilder) Header(text string) *BlockBuilder {
+	return b.Add(HeaderBlock{Text: PlainText(text)})
+}
+
+// Section appends a section with text.
+func (b *BlockBuilder) Section(text TextObject) *BlockBuilder {
+	return b.Add(SectionBlock{Text: &text})
+}
+
+// Markdown appends a section with mrkdwn text.
+func (b *BlockBuilder) Markdown(text string) *BlockBuilder {
+	return b.Section(Markdown(text))
+}
+
+// Fields appends a section showing fields side by side.
+func (b *BlockBuilder) Fields(fields ...TextObject) *BlockBuilder {
+	return b.Add(SectionBlock{Fields: fields})
+}
+
+// Divider appends a divider.
+func (b *BlockBuilder) Divider() *BlockBuilder {
+	return b.Add(DividerBlock{})
+}
+
+// Context appends a context block of text and images.
+func (b *BlockBuilder) Context(elements ...Element) *BlockBuilder {
+	return b.Add(ContextBlock{Elements: elements})
+}
+
+// Image appends an image.
+func (b *BlockBuilder) Image(imageUrl string, altText string) *BlockBuilder {
+	return b.Add(ImageBlock{ImageUrl: imageUrl, AltText: altText})
+}
+
+// Actions appends buttons, selects or other interactive elements.
+func (b *BlockBuilder) Actions(elements ...Element) *BlockBuilder {
+	return b.Add(ActionsBlock{Elements: elements})
+}
+
+// RichText appends rich text sections, lists, quotes or code blocks.
+func (b *BlockBuilder) RichText(elements ...RichTextElement) *BlockBuilder {
+	return b.Add(RichTextBlock{Elements: elements})
+}
+
+// Call appends a Call.
+func (b *BlockBuilder) Call(callId string) *BlockBuilder {
+	return b.Add(CallBlock{CallId: callId})
+}
+
+// Build returns the blocks, or an error if they break Slack's limits.
+func (b *BlockBuilder) Build() (Blocks, error) {
+	if err := ValidateBlocks(b.blocks); err != nil {
+		return nil, err
+	}
+	return b.blocks, nil
+}
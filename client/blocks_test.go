@@ -0,0 +1,108 @@
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// Blocks encode with their type, as in the Block Kit reference.
+func TestBlocksMarshal(t *testing.T) {
+	blocks, err := client.NewBlockBuilder().
+		Header("Game night").
+		Markdown("Join us at *6:30pm*").
+		Divider().
+		Context(client.PlainText("Weekly"), client.ImageElement{ImageUrl: "http://img", AltText: "logo"}).
+		Actions(client.LinkButton("join", "Join", "http://zoom")).
+		RichText(client.RichTextList("bullet",
+			client.RichTextSection(client.RichTextText("bring snacks", &client.RichTextStyle{Bold: true})))).
+		Call("R123").
+		Build()
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	expected := `[` +
+		`{"type":"header","text":{"type":"plain_text","text":"Game night"}},` +
+		`{"type":"section","text":{"type":"mrkdwn","text":"Join us at *6:30pm*"}},` +
+		`{"type":"divider"},` +
+		`{"type":"context","elements":[{"type":"plain_text","text":"Weekly"},{"type":"image","image_url":"http://img","alt_text":"logo"}]},` +
+		`{"type":"actions","elements":[{"type":"button","action_id":"join","text":{"type":"plain_text","text":"Join"},"url":"http://zoom"}]},` +
+		`{"type":"rich_text","elements":[{"style":"bullet","type":"rich_text_list","elements":[{"type":"rich_text_section","elements":[{"type":"text","text":"bring snacks","style":{"bold":true}}]}]}]},` +
+		`{"type":"call","call_id":"R123"}` +
+		`]`
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, data)
+	}
+
+	var decoded client.Blocks
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !reflect.DeepEqual(blocks, decoded) {
+		t.Errorf("Expected:\n%#v\nbut got:\n%#v", blocks, decoded)
+	}
+}
+
+// Blocks we don't model survive a round trip.
+func TestBlocksUnmarshalUnknown(t *testing.T) {
+	var blocks client.Blocks
+	data := `[{"type":"video","title":{"type":"plain_text","text":"x"}},{"type":"section","text":{"type":"mrkdwn","text":"hi"},"accessory":{"type":"datepicker"}}]`
+	if err := json.Unmarshal([]byte(data), &blocks); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].BlockType() != "video" {
+		t.Fatalf("Unexpected blocks: %#v", blocks)
+	}
+	if section, ok := blocks[1].(client.SectionBlock); !ok || section.Accessory.ElementType() != "datepicker" {
+		t.Errorf("Unexpected section: %#v", blocks[1])
+	}
+}
+
+func TestValidateBlocks(t *testing.T) {
+	tooMany := client.NewBlockBuilder()
+	for i := 0; i <= client.MaxMessageBlocks; i++ {
+		tooMany.Divider()
+	}
+	var fields []client.TextObject
+	for i := 0; i <= client.MaxSectionFields; i++ {
+		fields = append(fields, client.PlainText("field"))
+	}
+
+	for _, test := range []struct {
+		name    string
+		builder *client.BlockBuilder
+		reason  string
+	}{
+		{"too many blocks", tooMany, "51 blocks"},
+		{"long header", client.NewBlockBuilder().Header(strings.Repeat("x", 151)), "more than 150"},
+		{"markdown header", client.NewBlockBuilder().Add(client.HeaderBlock{Text: client.Markdown("x")}), "must be plain_text"},
+		{"long section", client.NewBlockBuilder().Markdown(strings.Repeat("x", 3001)), "more than 3000"},
+		{"empty section", client.NewBlockBuilder().Add(client.SectionBlock{}), "needs text or fields"},
+		{"too many fields", client.NewBlockBuilder().Fields(fields...), "11 fields"},
+		{"long button", client.NewBlockBuilder().Actions(client.LinkButton("a", strings.Repeat("x", 76), "http://zoom")), "more than 75"},
+		{"button in context", client.NewBlockBuilder().Context(client.LinkButton("a", "b", "c")), "can't contain button"},
+		{"empty select", client.NewBlockBuilder().Actions(client.StaticSelectElement{}), "needs 1 to 100 options"},
+		{"long block_id", client.NewBlockBuilder().Add(client.DividerBlock{BlockId: strings.Repeat("x", 256)}), "more than 255"},
+		{"bad list style", client.NewBlockBuilder().RichText(client.RichTextList("dashed")), "unknown style"},
+	} {
+		_, err := test.builder.Build()
+		var blockErr *client.BlockError
+		if !errors.As(err, &blockErr) || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%s: expected a BlockError containing %q, got: %v", test.name, test.reason, err)
+		}
+	}
+
+	// Limits count characters, not bytes.
+	if _, err := client.NewBlockBuilder().Header(strings.Repeat("é", 150)).Build(); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+// Bookmark is a link pinned to the top of a channel.
+type Bookmark struct {
+	Id        string `json:"id"`
+	ChannelId string `json:"channel_id"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	Emoji     string `json:"emoji,omitempty"`
+	IconUrl   string `json:"icon_url,omitempty"`
+	// Type is "link" for bookmarks added through the API.
+	Type        string `json:"type"`
+	DateCreated int64  `json:"date_created"`
+	DateUpdated int64  `json:"date_updated"`
+	Rank        string `json:"rank,omitempty"`
+	// LastUpdatedByUserId is who added or last edited the bookmark.
+	LastUpdatedByUserId string `json:"last_updated_by_user_id,omitempty"`
+}
+
+// BookmarkLink is the only bookmark type that can be added.
+const BookmarkLink = "link"
+
+// bookmarks.add request. Uses BookmarkResponse.
+type BookmarksAddRequest struct {
+	ChannelId string `json:"channel_id"`
+	Title     string `json:"title"`
+	// Type must be BookmarkLink.
+	Type  string `json:"type"`
+	Link  string `json:"link"`
+	Emoji string `json:"emoji,omitempty"`
+}
+
+// bookmarks.edit request. Uses BookmarkResponse.
+// Only the fields set are changed.
+type BookmarksEditRequest struct {
+	ChannelId  string `json:"channel_id"`
+	BookmarkId string `json:"bookmark_id"`
+	Title      string `json:"title,omitempty"`
+	Link       string `json:"link,omitempty"`
+	Emoji      string `json:"emoji,omitempty"`
+}
+
+// bookmarks.remove request. Uses GenericResponse.
+type BookmarksRemoveRequest struct {
+	ChannelId  string `json:"channel_id"`
+	BookmarkId string `json:"bookmark_id"`
+}
+
+// bookmarks.list request. Uses BookmarksListResponse.
+type BookmarksListRequest struct {
+	ChannelId string `json:"channel_id"`
+}
+
+// BookmarkResponse is the response to bookmarks.add and bookmarks.edit.
+type BookmarkResponse struct {
+	SlackResponse
+	Bookmark Bookmark `json:"bookmark"`
+}
+
+type BookmarksListResponse struct {
+	SlackResponse
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+func (r BookmarksAddRequest) Verb() string {
+	return "POST"
+}
+
+func (r BookmarksAddRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r BookmarksAddRequest) Method() string {
+	return "bookmarks.add"
+}
+
+func (r BookmarksEditRequest) Verb() string {
+	return "POST"
+}
+
+func (r BookmarksEditRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r BookmarksEditRequest) Method() string {
+	return "bookmarks.edit"
+}
+
+func (r BookmarksRemoveRequest) Verb() string {
+	return "POST"
+}
+
+func (r BookmarksRemoveRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r BookmarksRemoveRequest) Method() string {
+	return "bookmarks.remove"
+}
+
+func (r BookmarksListRequest) Verb() string {
+	return "POST"
+}
+
+func (r BookmarksListRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r BookmarksListRequest) Method() string {
+	return "bookmarks.list"
+}
@@ -0,0 +1,49 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+func TestBookmarksAdd(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(gomock.All(
+		HasUrl("https://slack.com/api/bookmarks.add"),
+		HasJsonBody(`{"channel_id": "C1", "title": "Video Call", "type": "link", "link": "http://zoom"}`))).Return(
+		HttpResponseWithBody(`{
+			"ok": true,
+			"bookmark": {
+				"id": "Bk01",
+				"channel_id": "C1",
+				"title": "Video Call",
+				"link": "http://zoom",
+				"type": "link",
+				"date_created": 1791298800,
+				"date_updated": 1791298800,
+				"rank": "U",
+				"last_updated_by_user_id": "UBOT"
+			}
+		}`), nil).Times(1)
+
+	var actual client.BookmarkResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.BookmarksAddRequest{
+			ChannelId: "C1", Title: "Video Call", Type: client.BookmarkLink, Link: "http://zoom"},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.BookmarkResponse{
+			SlackResponse: client.SlackResponse{Ok: true},
+			Bookmark: client.Bookmark{
+				Id:                  "Bk01",
+				ChannelId:           "C1",
+				Title:               "Video Call",
+				Link:                "http://zoom",
+				Type:                "link",
+				DateCreated:         1791298800,
+				DateUpdated:         1791298800,
+				Rank:                "U",
+				LastUpdatedByUserId: "UBOT",
+			}})
+}
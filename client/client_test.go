@@ -2,6 +2,7 @@ package client_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/golang/mock/gomock"
@@ -13,6 +14,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Based on example response from https://api.slack.com/methods/conversations.create
@@ -58,12 +60,21 @@ const createChannelResponseSuccess = `
     }
 }`
 
-func getClient(t *testing.T, token string) (*mocks.MockHttpClientInterface, client.Client) {
+// createdChannel is the channel in createChannelResponseSuccess.
+var createdChannel = client.Channel{
+	Id:            "C0EAQDV4Z",
+	Name:          "new-channel-name",
+	Created:       1504554479,
+	Creator:       "U0123456",
+	PreviousNames: []string{},
+}
+
+func getClient(t *testing.T, token string, opts ...client.Option) (*mocks.MockHttpClientInterface, client.Client) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	mockHttp := mocks.NewMockHttpClientInterface(mockCtrl)
-	slackClient := client.NewClientWithHttpClient(mockHttp, token)
+	slackClient := client.NewClientWithHttpClient(mockHttp, token, opts...)
 	return mockHttp, slackClient
 }
 
@@ -173,8 +184,103 @@ func TestCreateChannel(t *testing.T) {
 		/*req=*/ client.CreateChannelRequest{Name: "new-channel-name"},
 		/*actual=*/ &actual,
 		/*expected=*/ &client.ChannelResponse{
-			Ok:      true,
-			Channel: client.Channel{Id: "C0EAQDV4Z", Name: "new-channel-name"}})
+			SlackResponse: client.SlackResponse{Ok: true},
+			Channel:       createdChannel})
+}
+
+// Test an "ok": false response.
+func TestCreateChannelNameTaken(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/conversations.create")).Return(
+		HttpResponseWithBody(`
+{
+    "ok": false,
+    "error": "name_taken",
+    "detail": "A channel with that name exists",
+    "warning": "missing_charset",
+    "response_metadata": {
+        "warnings": ["missing_charset"],
+        "messages": ["[WARN] A Content-Type HTTP header was presented but did not declare a charset"]
+    }
+}`), nil).Times(1)
+
+	var resp client.ChannelResponse
+	_, err := slackClient.Execute(
+		/*req=*/ client.CreateChannelRequest{Name: "new-channel-name"},
+		/*resp=*/ &resp)
+
+	if !client.IsNameTaken(err) {
+		t.Fatalf("Expected name_taken, got: %v", err)
+	}
+	if client.IsAlreadyInChannel(err) || client.IsMissingScope(err) || client.IsRateLimited(err) {
+		t.Errorf("Unexpected error classification: %v", err)
+	}
+
+	var slackErr *client.SlackError
+	errors.As(err, &slackErr)
+	expected := &client.SlackError{
+		Method:   "conversations.create",
+		Code:     "name_taken",
+		Detail:   "A channel with that name exists",
+		Warnings: []string{"missing_charset", "missing_charset"},
+		Messages: []string{
+			"[WARN] A Content-Type HTTP header was presented but did not declare a charset"},
+	}
+	if !reflect.DeepEqual(expected, slackErr) {
+		t.Errorf("Expected:\n%+v\n\nbut got:\n%+v", expected, slackErr)
+	}
+	if resp.Error != "name_taken" {
+		t.Errorf("Response wasn't populated: %+v", resp)
+	}
+}
+
+// Test a missing_scope error.
+func TestMissingScope(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list")).Return(
+		HttpResponseWithBody(
+			`{"ok": false, "error": "missing_scope", "needed": "users:read", "provided": "channels:manage"}`),
+		nil).Times(1)
+
+	var resp client.UsersListResponse
+	_, err := slackClient.Execute(client.UsersListRequest{}, &resp)
+
+	if !client.IsMissingScope(err) {
+		t.Fatalf("Expected missing_scope, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "needed scope: users:read") {
+		t.Errorf("Error doesn't mention the needed scope: %v", err)
+	}
+}
+
+// Requests go to the configured base URL.
+func TestWithBaseURL(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token",
+		client.WithBaseURL("http://localhost:8080/api/"))
+
+	gomock.InOrder(
+		mockHttp.EXPECT().Do(gomock.All(
+			HasToken("my-auth-token"),
+			HasUrl("http://localhost:8080/api/conversations.create"),
+			HasJsonBody(`{"name": "new-channel-name"}`))).Return(
+			HttpResponseWithBody(createChannelResponseSuccess), nil).Times(1),
+		mockHttp.EXPECT().Do(
+			HasUrl("http://localhost:8080/api/users.list?cursor=abc&limit=10")).Return(
+			HttpResponseWithBody(`{"ok": true}`), nil).Times(1))
+
+	var channelResp client.ChannelResponse
+	if _, err := slackClient.Execute(
+		client.CreateChannelRequest{Name: "new-channel-name"}, &channelResp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+
+	var usersResp client.UsersListResponse
+	if _, err := slackClient.Execute(
+		client.UsersListRequest{Cursor: "abc", Limit: "10"}, &usersResp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
 }
 
 // Test a non-200 error.
@@ -226,6 +332,257 @@ func TestTransportError(t *testing.T) {
 	}
 }
 
+// A cancelled context is passed through to the HTTP request.
+func TestExecuteContextCancelled(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mockHttp.EXPECT().Do(gomock.All(
+		HasToken("my-auth-token"),
+		HasUrl("https://slack.com/api/conversations.create"))).DoAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}).Times(1)
+
+	var resp client.ChannelResponse
+	_, err := slackClient.ExecuteContext(ctx,
+		/*req=*/ client.CreateChannelRequest{Name: "new-channel-name"},
+		/*resp=*/ &resp)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
+
+func HttpRateLimitedResponse(retryAfter string) *http.Response {
+	return &http.Response{
+		Status:     "429 Too Many Requests",
+		StatusCode: 429,
+		Header:     http.Header{"Retry-After": []string{retryAfter}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"ok":false,"error":"ratelimited"}`)),
+	}
+}
+
+// A 429 is retried after waiting for Retry-After.
+func TestRateLimitedRetry(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	gomock.InOrder(
+		mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/conversations.create")).Return(
+			HttpRateLimitedResponse("1"), nil).Times(1),
+		mockHttp.EXPECT().Do(gomock.All(
+			HasUrl("https://slack.com/api/conversations.create"),
+			HasJsonBody(`{"name": "new-channel-name"}`))).Return(
+			HttpResponseWithBody(createChannelResponseSuccess), nil).Times(1))
+
+	start := time.Now()
+	var actual client.ChannelResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.CreateChannelRequest{Name: "new-channel-name"},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.ChannelResponse{
+			SlackResponse: client.SlackResponse{Ok: true},
+			Channel:       createdChannel})
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Retried after %v, before Retry-After passed", elapsed)
+	}
+}
+
+// Once out of retries, the RateLimitError is returned.
+func TestRateLimitedGivesUp(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token", client.WithMaxRateLimitRetries(0))
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list")).Return(
+		HttpRateLimitedResponse("30"), nil).Times(1)
+
+	var resp client.UsersListResponse
+	_, err := slackClient.Execute(client.UsersListRequest{}, &resp)
+
+	var rateLimitErr *client.RateLimitError
+	if !client.IsRateLimited(err) || !errors.As(err, &rateLimitErr) {
+		t.Fatalf("Expected a RateLimitError, got: %v", err)
+	}
+	if rateLimitErr.Method != "users.list" || rateLimitErr.RetryAfter != 30*time.Second {
+		t.Errorf("Unexpected RateLimitError: %+v", rateLimitErr)
+	}
+}
+
+// Retries quickly, for tests.
+var fastRetries = client.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+}
+
+func HttpResponseWithStatus(code int) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(code),
+		StatusCode: code,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("error\r\n")),
+	}
+}
+
+// A GET request is retried after a 5xx.
+func TestRetryIdempotentRequest(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token", client.WithRetryPolicy(fastRetries))
+
+	gomock.InOrder(
+		mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list")).Return(
+			HttpResponseWithStatus(503), nil).Times(1),
+		mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list")).Return(
+			nil, errors.New("connection reset")).Times(1),
+		mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list")).Return(
+			HttpResponseWithBody(`{"ok": true, "members": [{"id": "123", "name": "foo"}]}`),
+			nil).Times(1))
+
+	var actual client.UsersListResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.UsersListRequest{},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.UsersListResponse{
+			SlackResponse: client.SlackResponse{Ok: true},
+			Members:       []client.User{client.User{Id: "123", Name: "foo"}},
+		})
+}
+
+// A GET request gives up after MaxAttempts.
+func TestRetryGivesUp(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token", client.WithRetryPolicy(fastRetries))
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list")).Return(
+		HttpResponseWithStatus(500), nil).Times(3)
+
+	var resp client.UsersListResponse
+	_, err := slackClient.Execute(client.UsersListRequest{}, &resp)
+
+	var statusErr *client.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 500 {
+		t.Errorf("Expected a StatusError with code 500, got: %v", err)
+	}
+}
+
+// A POST request isn't retried after a 5xx, since it might have succeeded.
+func TestNoRetryNonIdempotentRequest(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token", client.WithRetryPolicy(fastRetries))
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/conversations.create")).Return(
+		HttpResponseWithStatus(502), nil).Times(1)
+
+	var resp client.ChannelResponse
+	_, err := slackClient.Execute(client.CreateChannelRequest{Name: "new-channel-name"}, &resp)
+	if err == nil {
+		t.Errorf("Failed to get an error!")
+	}
+}
+
+// A POST request is retried when Slack says nothing happened.
+func TestRetrySafeSlackError(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token", client.WithRetryPolicy(fastRetries))
+
+	gomock.InOrder(
+		mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/conversations.create")).Return(
+			HttpResponseWithBody(`{"ok": false, "error": "service_unavailable"}`), nil).Times(1),
+		mockHttp.EXPECT().Do(gomock.All(
+			HasUrl("https://slack.com/api/conversations.create"),
+			HasJsonBody(`{"name": "new-channel-name"}`))).Return(
+			HttpResponseWithBody(createChannelResponseSuccess), nil).Times(1))
+
+	var actual client.ChannelResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.CreateChannelRequest{Name: "new-channel-name"},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.ChannelResponse{
+			SlackResponse: client.SlackResponse{Ok: true},
+			Channel:       createdChannel})
+}
+
+// Successful ChannelInfoRequest, with the full Channel model.
+func TestChannelInfo(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(gomock.All(
+		HasToken("my-auth-token"),
+		HasUrl("https://slack.com/api/conversations.info?channel=C0EAQDV4Z&include_num_members=true"))).Return(
+		HttpResponseWithBody(`{
+			"ok": true,
+			"channel": {
+				"id": "C0EAQDV4Z",
+				"name": "20261006",
+				"created": 1791298800,
+				"creator": "UBOT",
+				"is_archived": true,
+				"is_private": true,
+				"num_members": 23,
+				"topic": {"value": "Video Call: http://zoom", "creator": "UBOT", "last_set": 1791298801},
+				"purpose": {"value": "Slack Call: R1", "creator": "UBOT", "last_set": 1791336600},
+				"previous_names": ["gamenight"]
+			}
+		}`), nil).Times(1)
+
+	var actual client.ChannelResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.ChannelInfoRequest{ChannelId: "C0EAQDV4Z", IncludeNumMembers: true},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.ChannelResponse{
+			SlackResponse: client.SlackResponse{Ok: true},
+			Channel: client.Channel{
+				Id:         "C0EAQDV4Z",
+				Name:       "20261006",
+				Created:    1791298800,
+				Creator:    "UBOT",
+				IsArchived: true,
+				IsPrivate:  true,
+				NumMembers: 23,
+				Topic: client.ChannelText{
+					Value: "Video Call: http://zoom", Creator: "UBOT", LastSet: 1791298801},
+				Purpose: client.ChannelText{
+					Value: "Slack Call: R1", Creator: "UBOT", LastSet: 1791336600},
+				PreviousNames: []string{"gamenight"},
+			}})
+
+	if created := actual.Channel.CreatedTime(); !created.Equal(time.Unix(1791298800, 0)) {
+		t.Errorf("Unexpected creation time %v", created)
+	}
+}
+
+// List and create requests expose channel types and archive filters.
+func TestChannelTypes(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	gomock.InOrder(
+		mockHttp.EXPECT().Do(gomock.All(
+			HasUrl("https://slack.com/api/conversations.create"),
+			HasJsonBody(`{"name": "new-channel-name", "is_private": true}`))).Return(
+			HttpResponseWithBody(createChannelResponseSuccess), nil).Times(1),
+		mockHttp.EXPECT().Do(
+			HasUrl("https://slack.com/api/conversations.list?exclude_archived=true&types=public_channel")).Return(
+			HttpResponseWithBody(`{"ok": true}`), nil).Times(1),
+		mockHttp.EXPECT().Do(
+			HasUrl("https://slack.com/api/conversations.list?types=public_channel%2Cprivate_channel")).Return(
+			HttpResponseWithBody(`{"ok": true}`), nil).Times(1))
+
+	var channelResp client.ChannelResponse
+	if _, err := slackClient.Execute(
+		client.CreateChannelRequest{Name: "new-channel-name", IsPrivate: true}, &channelResp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+
+	var listResp client.ChannelListResponse
+	if _, err := slackClient.Execute(client.ChannelListRequest{}, &listResp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := slackClient.Execute(client.ChannelListRequest{
+		Types:           []string{client.PublicChannel, client.PrivateChannel},
+		IncludeArchived: true,
+	}, &listResp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+}
+
 // Successful UsersListRequest (which uses GET, not POST).
 func TestUsersListRequest(t *testing.T) {
 	mockHttp, slackClient := getClient(t, "my-auth-token")
@@ -266,14 +623,16 @@ func TestUsersListRequest(t *testing.T) {
 			/*req=*/ client.UsersListRequest{},
 			/*actual=*/ &actual,
 			/*expected=*/ &client.UsersListResponse{
-				Ok: true,
+				SlackResponse: client.SlackResponse{
+					Ok: true,
+					Metadata: client.ResponseMetadata{
+						NextCursor: "XXXcontinuationXXX",
+					},
+				},
 				Members: []client.User{
 					client.User{Id: "123", Name: "foo"},
 					client.User{Id: "456", Name: "bar"},
 				},
-				Metadata: client.ResponseMetadata{
-					NextCursor: "XXXcontinuationXXX",
-				},
 			})
 	}
 
@@ -283,10 +642,83 @@ func TestUsersListRequest(t *testing.T) {
 			/*req=*/ client.UsersListRequest{Cursor: "XXXcontinuationXXX"},
 			/*actual=*/ &actual,
 			/*expected=*/ &client.UsersListResponse{
-				Ok: true,
+				SlackResponse: client.SlackResponse{Ok: true},
 				Members: []client.User{
 					client.User{Id: "789", Name: "baz"},
 				},
 			})
 	}
 }
+
+// Paginate follows cursors, keeping the page size.
+func TestPaginate(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	gomock.InOrder(
+		mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list?limit=2")).Return(
+			HttpResponseWithBody(`
+{
+  "ok": true,
+  "members": [{ "id": "123", "name": "foo" }, { "id": "456", "name": "bar" }],
+  "response_metadata": { "next_cursor": "page2" }
+}`), nil).Times(1),
+		mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.list?cursor=page2&limit=2")).Return(
+			HttpResponseWithBody(`
+{
+  "ok": true,
+  "members": [{ "id": "789", "name": "baz" }],
+  "response_metadata": { "next_cursor": "" }
+}`), nil).Times(1))
+
+	var names []string
+	var resp client.UsersListResponse
+	err := client.Paginate(context.Background(), slackClient,
+		client.UsersListRequest{Limit: "2"}, &resp, func() error {
+			for _, user := range resp.Members {
+				names = append(names, user.Name)
+			}
+			return nil
+		})
+
+	if err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if expected := []string{"foo", "bar", "baz"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+// Paginate stops early on ErrStopPagination, and returns other errors.
+func TestPaginateStop(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	page := `
+{
+  "ok": true,
+  "channels": [{ "id": "C1", "name": "general" }],
+  "response_metadata": { "next_cursor": "page2" }
+}`
+	listUrl := "https://slack.com/api/conversations.list?exclude_archived=true&types=public_channel"
+	mockHttp.EXPECT().Do(HasUrl(listUrl)).Return(HttpResponseWithBody(page), nil).Times(1)
+	mockHttp.EXPECT().Do(HasUrl(listUrl)).Return(HttpResponseWithBody(page), nil).Times(1)
+
+	var resp client.ChannelListResponse
+	pages := 0
+	err := client.Paginate(context.Background(), slackClient,
+		client.ChannelListRequest{}, &resp, func() error {
+			pages++
+			return client.ErrStopPagination
+		})
+	if err != nil || pages != 1 {
+		t.Errorf("Expected to stop after 1 page without error, got %d pages and %v", pages, err)
+	}
+
+	fnErr := errors.New("callback failed")
+	err = client.Paginate(context.Background(), slackClient,
+		client.ChannelListRequest{}, &resp, func() error {
+			return fnErr
+		})
+	if err != fnErr {
+		t.Errorf("Expected the callback's error, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Typed errors for Slack API failures.
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SlackError is returned by Execute when Slack answers with "ok": false.
+// The response is still decoded into resp, for callers needing more context.
+// See https://api.slack.com/web#evaluating_responses
+type SlackError struct {
+	// Method is the API method called, e.g. "conversations.create".
+	Method string
+	// Code is the machine readable error, e.g. "name_taken".
+	Code string
+	// Detail is the human readable detail some methods return.
+	Detail string
+	// Warnings lists any warnings returned alongside the error.
+	Warnings []string
+	// Messages holds response_metadata.messages, which explain
+	// invalid arguments.
+	Messages []string
+	// Needed and Provided list the scopes for missing_scope errors.
+	Needed   string
+	Provided string
+}
+
+func (e *SlackError) Error() string {
+	msg := fmt.Sprintf("Slack error calling %s: %s", e.Method, e.Code)
+	if len(e.Detail) > 0 {
+		msg += " (" + e.Detail + ")"
+	}
+	if len(e.Needed) > 0 {
+		msg += ", needed scope: " + e.Needed
+	}
+	if len(e.Messages) > 0 {
+		msg += "\n" + strings.Join(e.Messages, "\n")
+	}
+	return msg
+}
+
+// parseSlackError returns a *SlackError if respJson is an "ok": false
+// response, and nil otherwise.
+func parseSlackError(method string, respJson string) *SlackError {
+	var envelope struct {
+		SlackResponse
+		Needed   string `json:"needed"`
+		Provided string `json:"provided"`
+	}
+	if err := json.Unmarshal([]byte(respJson), &envelope); err != nil || envelope.Ok {
+		return nil
+	}
+
+	slackErr := &SlackError{
+		Method:   method,
+		Code:     envelope.Error,
+		Detail:   envelope.ErrorDetail,
+		Messages: envelope.Metadata.Messages,
+		Needed:   envelope.Needed,
+		Provided: envelope.Provided,
+	}
+	if len(envelope.Warning) > 0 {
+		slackErr.Warnings = strings.Split(envelope.Warning, ",")
+	}
+	slackErr.Warnings = append(slackErr.Warnings, envelope.Metadata.Warnings...)
+	return slackErr
+}
+
+// IsSlackError reports whether err is a *SlackError with the given code.
+func IsSlackError(err error, code string) bool {
+	var slackErr *SlackError
+	return errors.As(err, &slackErr) && slackErr != nil && slackErr.Code == code
+}
+
+// IsNameTaken reports whether a channel couldn't be created or renamed
+// because the name is in use.
+func IsNameTaken(err error) bool {
+	return IsSlackError(err, "name_taken")
+}
+
+// IsAlreadyInChannel reports whether an invite failed because the users
+// are already members.
+func IsAlreadyInChannel(err error) bool {
+	return IsSlackError(err, "already_in_channel")
+}
+
+// IsMissingScope reports whether the token lacks a scope the method needs.
+func IsMissingScope(err error) bool {
+	return IsSlackError(err, "missing_scope")
+}
+
+// IsRateLimited reports whether the request was rate limited, either by
+// HTTP 429 or a "ratelimited" error.
+func IsRateLimited(err error) bool {
+	var rateLimitErr *RateLimitError
+	return errors.As(err, &rateLimitErr) || IsSlackError(err, "ratelimited")
+}
@@ -0,0 +1,489 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a message, as returned by conversations.history and
+// conversations.replies.
+type Message struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype,omitempty"`
+	User    string `json:"user,omitempty"`
+	BotId   string `json:"bot_id,omitempty"`
+	Text    string `json:"text"`
+	// Ts identifies the message within its channel.
+	Ts string `json:"ts"`
+
+	// ThreadTs is the Ts of the thread's parent, for threaded messages and
+	// their parents.
+	ThreadTs    string   `json:"thread_ts,omitempty"`
+	ReplyCount  int      `json:"reply_count,omitempty"`
+	ReplyUsers  []string `json:"reply_users,omitempty"`
+	LatestReply string   `json:"latest_reply,omitempty"`
+
+	Blocks    Blocks     `json:"blocks,omitempty"`
+	Reactions []Reaction `json:"reactions,omitempty"`
+	Files     []File     `json:"files,omitempty"`
+	// PinnedTo lists the channels the message is pinned in.
+	PinnedTo []string `json:"pinned_to,omitempty"`
+}
+
+// Time returns when the message was posted.
+func (m Message) Time() time.Time {
+	t, _ := ParseTs(m.Ts)
+	return t
+}
+
+// IsThreadParent reports whether the message started a thread.
+func (m Message) IsThreadParent() bool {
+	return len(m.ThreadTs) > 0 && m.ThreadTs == m.Ts
+}
+
+// IsReply reports whether the message is a reply in a thread.
+func (m Message) IsReply() bool {
+	return len(m.ThreadTs) > 0 && m.ThreadTs != m.Ts
+}
+
+// IsPinned reports whether the message is pinned in its channel.
+func (m Message) IsPinned(channelId string) bool {
+	for _, pinned := range m.PinnedTo {
+		if pinned == channelId {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaction is an emoji reaction to a message.
+type Reaction struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Users []string `json:"users"`
+}
+
+// File is a file shared in a message.
+type File struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	Title      string `json:"title"`
+	Mimetype   string `json:"mimetype"`
+	Filetype   string `json:"filetype"`
+	Size       int64  `json:"size"`
+	User       string `json:"user"`
+	UrlPrivate string `json:"url_private"`
+	Permalink  string `json:"permalink"`
+}
+
+// ParseTs converts a message timestamp, e.g. "1355517523.000005", to a
+// time.Time.
+func ParseTs(ts string) (time.Time, error) {
+	parts := strings.SplitN(ts, ".", 2)
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid ts %q", ts)
+	}
+	var nsec int64
+	if len(parts) == 2 && len(parts[1]) > 0 {
+		frac := parts[1]
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		frac += strings.Repeat("0", 9-len(frac))
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("Invalid ts %q", ts)
+		}
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// FormatTs converts a time.Time to a timestamp, for the oldest and latest
+// filters.
+func FormatTs(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1000)
+}
+
+// EscapeText escapes &, < and >, the way Slack stores them in a message's
+// text.
+func EscapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// FormatLink formats url as a link in a message's text. Slack keeps the
+// links it's sent as they are, while it rewrites the rest of the text.
+func FormatLink(url string) string {
+	return "<" + EscapeText(url) + ">"
+}
+
+// TextLinks returns the URLs linked in a message's text, unescaped.
+func TextLinks(text string) []string {
+	var urls []string
+	for _, match := range linkPattern.FindAllStringSubmatch(text, -1) {
+		urls = append(urls, textUnescaper.Replace(match[1]))
+	}
+	return urls
+}
+
+// linkPattern matches <url> and <url|label>.
+var linkPattern = regexp.MustCompile(`<(https?://[^|<>]+)(?:\|[^<>]*)?>`)
+
+var textUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+// MessageFilter limits the messages of conversations.history and
+// conversations.replies to a time range. Oldest and Latest are timestamps,
+// see FormatTs.
+type MessageFilter struct {
+	Oldest string
+	Latest string
+	// Inclusive includes messages at exactly Oldest or Latest.
+	Inclusive bool
+}
+
+func (f MessageFilter) setQuery(query url.Values) {
+	if len(f.Oldest) > 0 {
+		query.Set("oldest", f.Oldest)
+	}
+	if len(f.Latest) > 0 {
+		query.Set("latest", f.Latest)
+	}
+	if f.Inclusive {
+		query.Set("inclusive", "true")
+	}
+}
+
+// conversations.history request. Uses MessagesResponse.
+// Messages are returned newest first, without thread replies.
+type ConversationsHistoryRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	ChannelId string
+	MessageFilter
+	Cursor string
+	Limit  string
+}
+
+// conversations.replies request. Uses MessagesResponse.
+// The thread's parent is returned first, followed by its replies, oldest
+// first.
+type ConversationsRepliesRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	ChannelId string
+	// Ts of the thread's parent message.
+	Ts string
+	MessageFilter
+	Cursor string
+	Limit  string
+}
+
+type MessagesResponse struct {
+	SlackResponse
+	Messages []Message `json:"messages"`
+	HasMore  bool      `json:"has_more"`
+}
+
+func (r ConversationsHistoryRequest) Verb() string {
+	return "GET"
+}
+
+func (r ConversationsHistoryRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r ConversationsHistoryRequest) Method() string {
+	return "conversations.history"
+}
+
+func (r ConversationsHistoryRequest) Query() url.Values {
+	query := url.Values{}
+	query.Set("channel", r.ChannelId)
+	r.MessageFilter.setQuery(query)
+	if len(r.Cursor) > 0 {
+		query.Set("cursor", r.Cursor)
+	}
+	if len(r.Limit) > 0 {
+		query.Set("limit", r.Limit)
+	}
+	return query
+}
+
+func (r ConversationsHistoryRequest) WithCursor(cursor string) PaginatedRequest {
+	r.Cursor = cursor
+	return r
+}
+
+func (r ConversationsRepliesRequest) Verb() string {
+	return "GET"
+}
+
+func (r ConversationsRepliesRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r ConversationsRepliesRequest) Method() string {
+	return "conversations.replies"
+}
+
+func (r ConversationsRepliesRequest) Query() url.Values {
+	query := url.Values{}
+	query.Set("channel", r.ChannelId)
+	query.Set("ts", r.Ts)
+	r.MessageFilter.setQuery(query)
+	if len(r.Cursor) > 0 {
+		query.Set("cursor", r.Cursor)
+	}
+	if len(r.Limit) > 0 {
+		query.Set("limit", r.Limit)
+	}
+	return query
+}
+
+func (r ConversationsRepliesRequest) WithCursor(cursor string) PaginatedRequest {
+	r.Cursor = cursor
+	return r
+}
+
+// chat.scheduleMessage request. Uses ScheduleMessageResponse.
+// Fails with "time_in_past" if PostAt has passed, and "time_too_far" if it's
+// more than 120 days away.
+type ScheduleMessageRequest struct {
+	ChannelId string `json:"channel"`
+	Text      string `json:"text"`
+	// PostAt is when to post the message, as a Unix time.
+	PostAt   int64  `json:"post_at"`
+	Blocks   Blocks `json:"blocks,omitempty"`
+	ThreadTs string `json:"thread_ts,omitempty"`
+}
+
+type ScheduleMessageResponse struct {
+	SlackResponse
+	Channel            string  `json:"channel"`
+	ScheduledMessageId string  `json:"scheduled_message_id"`
+	PostAt             int64   `json:"post_at"`
+	Message            Message `json:"message"`
+}
+
+// ScheduledMessage is a message waiting to be posted, as returned by
+// chat.scheduledMessages.list.
+type ScheduledMessage struct {
+	Id          string `json:"id"`
+	ChannelId   string `json:"channel_id"`
+	PostAt      int64  `json:"post_at"`
+	DateCreated int64  `json:"date_created"`
+	Text        string `json:"text"`
+}
+
+// PostTime returns when the message will be posted.
+func (m ScheduledMessage) PostTime() time.Time {
+	return time.Unix(m.PostAt, 0)
+}
+
+// chat.scheduledMessages.list request. Uses ScheduledMessagesListResponse.
+// Only lists the messages scheduled by the token's user.
+type ScheduledMessagesListRequest struct {
+	// ChannelId limits the list to one channel.
+	ChannelId string `json:"channel,omitempty"`
+	// Oldest and Latest limit the list to messages posted in a range of
+	// Unix times.
+	Oldest string `json:"oldest,omitempty"`
+	Latest string `json:"latest,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+type ScheduledMessagesListResponse struct {
+	SlackResponse
+	ScheduledMessages []ScheduledMessage `json:"scheduled_messages"`
+}
+
+// chat.deleteScheduledMessage request. Uses GenericResponse.
+// Fails with "invalid_scheduled_message_id" if the message was already
+// posted or deleted.
+type DeleteScheduledMessageRequest struct {
+	ChannelId          string `json:"channel"`
+	ScheduledMessageId string `json:"scheduled_message_id"`
+}
+
+func (r ScheduleMessageRequest) Verb() string {
+	return "POST"
+}
+
+func (r ScheduleMessageRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r ScheduleMessageRequest) Method() string {
+	return "chat.scheduleMessage"
+}
+
+func (r ScheduledMessagesListRequest) Verb() string {
+	return "POST"
+}
+
+func (r ScheduledMessagesListRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r ScheduledMessagesListRequest) Method() string {
+	return "chat.scheduledMessages.list"
+}
+
+func (r ScheduledMessagesListRequest) WithCursor(cursor string) PaginatedRequest {
+	r.Cursor = cursor
+	return r
+}
+
+func (r DeleteScheduledMessageRequest) Verb() string {
+	return "POST"
+}
+
+func (r DeleteScheduledMessageRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r DeleteScheduledMessageRequest) Method() string {
+	return "chat.deleteScheduledMessage"
+}
+
+// chat.update request. Uses PostMessageResponse.
+// Bots can only update their own messages.
+type UpdateMessageRequest struct {
+	ChannelId string `json:"channel"`
+	// Ts of the message to update.
+	Ts     string `json:"ts"`
+	Text   string `json:"text"`
+	Blocks Blocks `json:"blocks,omitempty"`
+}
+
+// chat.delete request. Uses GenericResponse.
+type DeleteMessageRequest struct {
+	ChannelId string `json:"channel"`
+	Ts        string `json:"ts"`
+}
+
+// pins.add request. Uses GenericResponse.
+// Fails with "already_pinned" if the message is pinned.
+type PinsAddRequest struct {
+	ChannelId string `json:"channel"`
+	// Timestamp is the Ts of the message.
+	Timestamp string `json:"timestamp"`
+}
+
+// pins.remove request. Uses GenericResponse.
+// Fails with "no_pin" if the message isn't pinned.
+type PinsRemoveRequest struct {
+	ChannelId string `json:"channel"`
+	Timestamp string `json:"timestamp"`
+}
+
+// reactions.add request. Uses GenericResponse.
+// Fails with "already_reacted" if the token's user already added it.
+type ReactionsAddRequest struct {
+	ChannelId string `json:"channel"`
+	Timestamp string `json:"timestamp"`
+	// Name is the emoji, without colons, e.g. "thumbsup".
+	Name string `json:"name"`
+}
+
+// reactions.get request. Uses ReactionsGetResponse.
+type ReactionsGetRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	ChannelId string
+	Timestamp string
+	// Full lists every user who reacted, rather than a sample.
+	Full bool
+}
+
+type ReactionsGetResponse struct {
+	SlackResponse
+	Type    string  `json:"type"`
+	Channel string  `json:"channel"`
+	Message Message `json:"message"`
+}
+
+func (r UpdateMessageRequest) Verb() string {
+	return "POST"
+}
+
+func (r UpdateMessageRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r UpdateMessageRequest) Method() string {
+	return "chat.update"
+}
+
+func (r DeleteMessageRequest) Verb() string {
+	return "POST"
+}
+
+func (r DeleteMessageRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r DeleteMessageRequest) Method() string {
+	return "chat.delete"
+}
+
+func (r PinsAddRequest) Verb() string {
+	return "POST"
+}
+
+func (r PinsAddRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r PinsAddRequest) Method() string {
+	return "pins.add"
+}
+
+func (r PinsRemoveRequest) Verb() string {
+	return "POST"
+}
+
+func (r PinsRemoveRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r PinsRemoveRequest) Method() string {
+	return "pins.remove"
+}
+
+func (r ReactionsAddRequest) Verb() string {
+	return "POST"
+}
+
+func (r ReactionsAddRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r ReactionsAddRequest) Method() string {
+	return "reactions.add"
+}
+
+func (r ReactionsGetRequest) Verb() string {
+	return "GET"
+}
+
+func (r ReactionsGetRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r ReactionsGetRequest) Method() string {
+	return "reactions.get"
+}
+
+func (r ReactionsGetRequest) Query() url.Values {
+	query := url.Values{}
+	query.Set("channel", r.ChannelId)
+	query.Set("timestamp", r.Timestamp)
+	if r.Full {
+		query.Set("full", "true")
+	}
+	return query
+}
@@ -0,0 +1,157 @@
+package client_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+func TestTs(t *testing.T) {
+	when := time.Date(2026, 10, 6, 18, 30, 0, 5000, time.UTC)
+	ts := client.FormatTs(when)
+	if ts != "1791311400.000005" {
+		t.Errorf("Unexpected ts %q", ts)
+	}
+	if parsed, err := client.ParseTs(ts); err != nil || !parsed.Equal(when) {
+		t.Errorf("Expected %v, got %v, %v", when, parsed, err)
+	}
+	if parsed, err := client.ParseTs("1791311400"); err != nil || parsed.Unix() != 1791311400 {
+		t.Errorf("Got %v, %v", parsed, err)
+	}
+	if _, err := client.ParseTs("yesterday"); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	if escaped := client.EscapeText("Games & <chat>"); escaped != "Games &amp; &lt;chat&gt;" {
+		t.Errorf("Unexpected escaping %q", escaped)
+	}
+}
+
+func TestTextLinks(t *testing.T) {
+	text := "Join " + client.FormatLink("http://zoom/j?a=1&b=2") +
+		" or <https://meet/x|Meet> with <@U1>, not &lt;http://fake&gt;"
+	links := client.TextLinks(text)
+	if !reflect.DeepEqual(links, []string{"http://zoom/j?a=1&b=2", "https://meet/x"}) {
+		t.Errorf("Unexpected links %q in %q", links, text)
+	}
+}
+
+const historyResponse = `{
+	"ok": true,
+	"messages": [{
+		"type": "message",
+		"user": "U1",
+		"text": "who's in?",
+		"ts": "1791311400.000005",
+		"thread_ts": "1791311400.000005",
+		"reply_count": 2,
+		"reply_users": ["U2", "U3"],
+		"reactions": [{"name": "thumbsup", "count": 2, "users": ["U2", "U3"]}],
+		"files": [{"id": "F1", "name": "board.png", "mimetype": "image/png", "size": 1024}]
+	}],
+	"has_more": true,
+	"response_metadata": {"next_cursor": "bmV4dA=="}
+}`
+
+// History requests filter by time, and decode the Message model.
+func TestConversationsHistory(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(HasUrl(
+		"https://slack.com/api/conversations.history?channel=C1&inclusive=true&limit=10&oldest=1791311400.000000")).Return(
+		HttpResponseWithBody(historyResponse), nil).Times(1)
+
+	var resp client.MessagesResponse
+	_, err := slackClient.Execute(client.ConversationsHistoryRequest{
+		ChannelId:     "C1",
+		MessageFilter: client.MessageFilter{Oldest: "1791311400.000000", Inclusive: true},
+		Limit:         "10",
+	}, &resp)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	if !resp.HasMore || resp.NextCursor() != "bmV4dA==" || len(resp.Messages) != 1 {
+		t.Fatalf("Unexpected response: %+v", resp)
+	}
+	msg := resp.Messages[0]
+	if !msg.IsThreadParent() || msg.ReplyCount != 2 || msg.Time().Unix() != 1791311400 {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+	if len(msg.Reactions) != 1 || msg.Reactions[0].Count != 2 ||
+		len(msg.Files) != 1 || msg.Files[0].Size != 1024 {
+		t.Errorf("Unexpected reactions or files: %+v", msg)
+	}
+}
+
+// Scheduled messages post at a Unix time.
+func TestScheduleMessage(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(gomock.All(
+		HasUrl("https://slack.com/api/chat.scheduleMessage"),
+		HasJsonBody(`{"channel": "C1", "text": "Game time!", "post_at": 1791336600}`))).Return(
+		HttpResponseWithBody(`{
+			"ok": true,
+			"channel": "C1",
+			"scheduled_message_id": "Q1298393284",
+			"post_at": 1791336600,
+			"message": {"type": "delayed_message", "text": "Game time!", "bot_id": "B1"}
+		}`), nil).Times(1)
+
+	var actual client.ScheduleMessageResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.ScheduleMessageRequest{ChannelId: "C1", Text: "Game time!", PostAt: 1791336600},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.ScheduleMessageResponse{
+			SlackResponse:      client.SlackResponse{Ok: true},
+			Channel:            "C1",
+			ScheduledMessageId: "Q1298393284",
+			PostAt:             1791336600,
+			Message:            client.Message{Type: "delayed_message", Text: "Game time!", BotId: "B1"},
+		})
+}
+
+// Reactions are read with a GET, and are part of the Message model.
+func TestReactionsGet(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/reactions.get?channel=C1&full=true&timestamp=1791311400.000005")).Return(
+		HttpResponseWithBody(`{
+			"ok": true,
+			"type": "message",
+			"channel": "C1",
+			"message": {
+				"type": "message",
+				"text": "Welcome!",
+				"ts": "1791311400.000005",
+				"pinned_to": ["C1"],
+				"reactions": [{"name": "tada", "count": 1, "users": ["U1"]}]
+			}
+		}`), nil).Times(1)
+
+	var actual client.ReactionsGetResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.ReactionsGetRequest{ChannelId: "C1", Timestamp: "1791311400.000005", Full: true},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.ReactionsGetResponse{
+			SlackResponse: client.SlackResponse{Ok: true},
+			Type:          "message",
+			Channel:       "C1",
+			Message: client.Message{
+				Type:      "message",
+				Text:      "Welcome!",
+				Ts:        "1791311400.000005",
+				PinnedTo:  []string{"C1"},
+				Reactions: []client.Reaction{{Name: "tada", Count: 1, Users: []string{"U1"}}},
+			},
+		})
+	if !actual.Message.IsPinned("C1") || actual.Message.IsPinned("C2") {
+		t.Errorf("Expected the message to be pinned in C1 only: %+v", actual.Message)
+	}
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	client "github.com/jaywhyzed/slackJanitor/client"
 	reflect "reflect"
@@ -48,16 +49,17 @@ func (mr *MockClientMockRecorder) Execute(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Execute", reflect.TypeOf((*MockClient)(nil).Execute), arg0, arg1)
 }
 
-// ExecuteOrDie mocks base method
-func (m *MockClient) ExecuteOrDie(arg0 client.Request, arg1 interface{}) string {
+// ExecuteContext mocks base method
+func (m *MockClient) ExecuteContext(arg0 context.Context, arg1 client.Request, arg2 interface{}) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ExecuteOrDie", arg0, arg1)
+	ret := m.ctrl.Call(m, "ExecuteContext", arg0, arg1, arg2)
 	ret0, _ := ret[0].(string)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// ExecuteOrDie indicates an expected call of ExecuteOrDie
-func (mr *MockClientMockRecorder) ExecuteOrDie(arg0, arg1 interface{}) *gomock.Call {
+// ExecuteContext indicates an expected call of ExecuteContext
+func (mr *MockClientMockRecorder) ExecuteContext(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteOrDie", reflect.TypeOf((*MockClient)(nil).ExecuteOrDie), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteContext", reflect.TypeOf((*MockClient)(nil).ExecuteContext), arg0, arg1, arg2)
 }
@@ -2,26 +2,46 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
 )
 
 type Request interface {
-	URL() string
+	// Method is the Slack API method, e.g. "conversations.create".
+	Method() string
 	Verb() string
+	// Tier is the Slack rate limit tier of the API method.
+	Tier() RateTier
 }
 
+// QueryRequest is implemented by GET requests with query parameters.
+type QueryRequest interface {
+	Request
+	Query() url.Values
+}
+
+// DefaultBaseURL is the base URL of Slack's Web API.
+const DefaultBaseURL = "https://slack.com/api/"
+
 //go:generate mockgen --destination=mocks/mock_client.go --package mocks . Client
 type Client interface {
 	// Execute makes an HTTP request with the given Request,
 	// and populates resp with the JSON response.
 	// Returns the raw response text, and an error (nil on success).
+	// If Slack answers with "ok": false, the error is a *SlackError.
 	Execute(req Request, resp interface{}) (string, error)
+
+	// ExecuteContext is like Execute, but the HTTP request is bound to ctx,
+	// so cancellation and deadlines abort the in-flight call.
+	ExecuteContext(ctx context.Context, req Request, resp interface{}) (string, error)
 }
 
 // HTTPClient interface, this is implemented by http.Client
@@ -33,48 +53,163 @@ type HttpClientInterface interface {
 type ClientImpl struct {
 	httpClient HttpClientInterface
 	token      string
+	// baseURL is prepended to the API method of each request.
+	baseURL string
+
+	// limiter throttles requests per API method. nil disables throttling.
+	limiter *rateLimiter
+	// maxRateLimitRetries is how often a request answered with HTTP 429
+	// is retried before giving up.
+	maxRateLimitRetries int
+	// retryPolicy controls retries after transient failures.
+	retryPolicy RetryPolicy
 }
 
-func NewClientWithHttpClient(httpClient HttpClientInterface, token string) Client {
-	return &ClientImpl{
-		httpClient: httpClient,
-		token:      token,
+// Option configures optional ClientImpl settings.
+type Option func(*ClientImpl)
+
+// WithRateLimits enables or disables client side throttling by rate tier.
+// Enabled by default.
+func WithRateLimits(enabled bool) Option {
+	return func(c *ClientImpl) {
+		if enabled {
+			c.limiter = newRateLimiter()
+		} else {
+			c.limiter = nil
+		}
 	}
 }
 
-func NewClient(token string) Client {
-	return NewClientWithHttpClient(&http.Client{}, token)
+// WithMaxRateLimitRetries sets how many times a rate limited request is
+// retried after waiting for Retry-After. Defaults to 3.
+func WithMaxRateLimitRetries(retries int) Option {
+	return func(c *ClientImpl) {
+		c.maxRateLimitRetries = retries
+	}
+}
+
+// WithBaseURL sends requests to a Slack compatible API at baseURL, such as
+// a local fake or a recording proxy, instead of DefaultBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *ClientImpl) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithRetryPolicy sets how transient failures are retried.
+// Defaults to DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *ClientImpl) {
+		c.retryPolicy = policy
+	}
+}
+
+func NewClientWithHttpClient(httpClient HttpClientInterface, token string, opts ...Option) Client {
+	c := &ClientImpl{
+		httpClient:          httpClient,
+		token:               token,
+		baseURL:             DefaultBaseURL,
+		limiter:             newRateLimiter(),
+		maxRateLimitRetries: 3,
+		retryPolicy:         DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func NewClient(token string, opts ...Option) Client {
+	return NewClientWithHttpClient(&http.Client{}, token, opts...)
 }
 
 // Populates resp, returns the raw json string and an error.
 func (c ClientImpl) Execute(req Request, resp interface{}) (string, error) {
-	// Reset the resp pointer in case it's not empty.
-	p := reflect.ValueOf(resp).Elem()
-	p.Set(reflect.Zero(p.Type()))
+	return c.ExecuteContext(context.Background(), req, resp)
+}
 
-	http_req, err := newRequest(req, c.token)
-	if err != nil {
-		return "", err
-	}
-	log.Printf("Calling URL %s", req.URL())
-	json, err := executeHttpReq(c.httpClient, http_req, resp)
-	if err != nil {
-		log.Printf("Got error making HTTP request: %v", err)
-	} else {
-		log.Printf("Got response:\n%+v", resp)
+// Like Execute, but aborts the call when ctx is done.
+func (c ClientImpl) ExecuteContext(ctx context.Context, req Request, resp interface{}) (string, error) {
+	method := req.Method()
+	reqUrl := c.URL(req)
+	rateLimitRetries := 0
+	for attempt := 1; ; attempt++ {
+		// Reset the resp pointer in case it's not empty.
+		p := reflect.ValueOf(resp).Elem()
+		p.Set(reflect.Zero(p.Type()))
+
+		if c.limiter != nil {
+			if err := c.limiter.wait(ctx, method, req.Tier()); err != nil {
+				return "", err
+			}
+		}
+
+		http_req, err := newRequest(ctx, reqUrl, req, c.token)
+		if err != nil {
+			return "", err
+		}
+		log.Printf("Calling URL %s", reqUrl)
+		json, err := executeHttpReq(c.httpClient, http_req, resp)
+		if err == nil {
+			if slackErr := parseSlackError(method, json); slackErr != nil {
+				err = slackErr
+			}
+		}
+
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) && rateLimitRetries < c.maxRateLimitRetries {
+			// Being throttled doesn't count as a failed attempt.
+			rateLimitRetries++
+			attempt--
+			log.Printf("%v, retrying", err)
+			if c.limiter != nil {
+				// The next wait() sleeps until Retry-After has passed.
+				c.limiter.block(method, rateLimitErr.RetryAfter)
+			} else if err := sleep(ctx, rateLimitErr.RetryAfter); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if attempt < c.retryPolicy.MaxAttempts && ctx.Err() == nil &&
+			isRetryable(req, err) {
+			backoff := c.retryPolicy.backoff(attempt)
+			log.Printf("Attempt %d calling %s failed, retrying in %v. Error: %v, response:\n%s",
+				attempt, method, backoff, err, json)
+			if err := sleep(ctx, backoff); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if err != nil {
+			log.Printf("Got error making HTTP request: %v", err)
+		} else {
+			log.Printf("Got response:\n%+v", resp)
+		}
+		return json, err
 	}
+}
 
-	return json, err
+// URL returns the full URL for req, including any query parameters.
+func (c ClientImpl) URL(req Request) string {
+	u := strings.TrimSuffix(c.baseURL, "/") + "/" + req.Method()
+	if queryReq, ok := req.(QueryRequest); ok {
+		if query := queryReq.Query().Encode(); len(query) > 0 {
+			u += "?" + query
+		}
+	}
+	return u
 }
 
-func newRequest(body Request, bearerToken string) (*http.Request, error) {
+func newRequest(ctx context.Context, reqUrl string, body Request, bearerToken string) (*http.Request, error) {
 	buf := new(bytes.Buffer)
 	if body.Verb() == "POST" {
 		log.Printf("Creating POST request with body:\n%v", body)
 		json.NewEncoder(buf).Encode(body)
 	}
-	log.Printf("Creating request with URL %s", body.URL())
-	req, err := http.NewRequest(body.Verb(), body.URL(), buf)
+	log.Printf("Creating request with URL %s", reqUrl)
+	req, err := http.NewRequestWithContext(ctx, body.Verb(), reqUrl, buf)
 	if err != nil {
 		return nil, errors.New("Error creating http.Request: " + err.Error())
 	}
@@ -84,16 +219,52 @@ func newRequest(body Request, bearerToken string) (*http.Request, error) {
 	return req, nil
 }
 
+// transportError is returned when the HTTP request couldn't be made at all.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return "Error executing request: " + e.err.Error()
+}
+
+// Unwrap lets callers detect context.Canceled and DeadlineExceeded.
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// StatusError is returned for non-200 HTTP responses, other than 429s
+// which return a RateLimitError.
+type StatusError struct {
+	StatusCode int
+	// Response is the formatted HTTP response, for logging.
+	Response string
+}
+
+func (e *StatusError) Error() string {
+	return "Got non-200 response:\n " + e.Response
+}
+
 func executeHttpReq(httpClient HttpClientInterface, req *http.Request, resp interface{}) (string, error) {
 	http_resp, err := httpClient.Do(req)
 
 	if err != nil {
-		return "", errors.New("Error executing request: " + err.Error())
+		return "", &transportError{err: err}
 	}
 	defer http_resp.Body.Close()
 
+	if http_resp.StatusCode == http.StatusTooManyRequests {
+		return "", &RateLimitError{
+			Method:     methodName(req.URL.String()),
+			RetryAfter: parseRetryAfter(http_resp.Header.Get("Retry-After")),
+		}
+	}
+
 	if http_resp.StatusCode != 200 {
-		return "", errors.New(fmt.Sprintf("Got non-200 response:\n %+v", http_resp))
+		return "", &StatusError{
+			StatusCode: http_resp.StatusCode,
+			Response:   fmt.Sprintf("%+v", http_resp),
+		}
 	}
 
 	raw_resp, err := ioutil.ReadAll(http_resp.Body)
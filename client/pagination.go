@@ -0,0 +1,67 @@
+// Cursor-based pagination, see https://api.slack.com/docs/pagination
+
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// PaginatedRequest is a Request for a method using cursor-based pagination.
+type PaginatedRequest interface {
+	Request
+	// WithCursor returns a copy of the request, fetching the page at cursor.
+	WithCursor(cursor string) PaginatedRequest
+}
+
+// PaginatedResponse is the response of a paginated method.
+// All responses embedding SlackResponse implement it.
+type PaginatedResponse interface {
+	// NextCursor returns the cursor of the next page, or "" on the last page.
+	NextCursor() string
+}
+
+// NextCursor implements PaginatedResponse.
+func (r SlackResponse) NextCursor() string {
+	return r.Metadata.NextCursor
+}
+
+// ErrStopPagination may be returned by a Paginate callback to stop fetching
+// pages. Paginate then returns nil.
+var ErrStopPagination = errors.New("stop pagination")
+
+// Paginate executes req, decoding each page into resp and calling fn,
+// until the last page. Any page size limit set on req applies to every page.
+// fn may return ErrStopPagination to stop early; other errors are returned
+// by Paginate, as are errors executing the requests.
+//
+//	var resp client.UsersListResponse
+//	err := client.Paginate(ctx, c, client.UsersListRequest{}, &resp, func() error {
+//		users = append(users, resp.Members...)
+//		return nil
+//	})
+func Paginate(ctx context.Context, c Client, req PaginatedRequest,
+	resp PaginatedResponse, fn func() error) error {
+	for {
+		// Clear the previous page, in case c doesn't.
+		p := reflect.ValueOf(resp).Elem()
+		p.Set(reflect.Zero(p.Type()))
+
+		if _, err := c.ExecuteContext(ctx, req, resp); err != nil {
+			return err
+		}
+
+		if err := fn(); err == ErrStopPagination {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		cursor := resp.NextCursor()
+		if len(cursor) == 0 {
+			return nil
+		}
+		req = req.WithCursor(cursor)
+	}
+}
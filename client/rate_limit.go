@@ -0,0 +1,162 @@
+// Client side throttling, following Slack's per-method rate limit tiers.
+// See https://api.slack.com/docs/rate-limits
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateTier is one of Slack's Web API rate limit tiers.
+type RateTier int
+
+const (
+	// Tier1 allows 1+ requests per minute.
+	Tier1 RateTier = iota + 1
+	// Tier2 allows 20+ requests per minute.
+	Tier2
+	// Tier3 allows 50+ requests per minute.
+	Tier3
+	// Tier4 allows 100+ requests per minute.
+	Tier4
+	// TierSpecial is used by methods like chat.postMessage, which allow
+	// roughly one request per second.
+	TierSpecial
+)
+
+// PerMinute returns the number of requests per minute allowed by the tier.
+func (t RateTier) PerMinute() int {
+	switch t {
+	case Tier1:
+		return 1
+	case Tier2:
+		return 20
+	case Tier3:
+		return 50
+	case Tier4:
+		return 100
+	case TierSpecial:
+		return 60
+	}
+	return 1
+}
+
+// RateLimitError is returned when Slack keeps answering HTTP 429.
+type RateLimitError struct {
+	Method     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("Rate limited calling %s, retry after %v", e.Method, e.RetryAfter)
+}
+
+// parseRetryAfter parses the Retry-After header, which Slack sends in seconds.
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// methodName returns the API method of a Slack URL, e.g. "users.list".
+func methodName(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return path.Base(u.Path)
+}
+
+// bucket is a token bucket for a single API method.
+type bucket struct {
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// rateLimiter throttles requests per method, allowing a burst of up to a
+// minute's worth of requests before spacing them out.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// reserve takes a token for method, and returns how long to wait before
+// making the request.
+func (l *rateLimiter) reserve(method string, tier RateTier) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacity := float64(tier.PerMinute())
+	now := l.now()
+	b, ok := l.buckets[method]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[method] = b
+	}
+
+	b.tokens += now.Sub(b.last).Minutes() * capacity
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.last = now
+	b.tokens--
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / capacity * float64(time.Minute))
+	}
+	if blocked := b.blockedUntil.Sub(now); blocked > wait {
+		wait = blocked
+	}
+	return wait
+}
+
+// block stops requests for method until d has passed, after a 429.
+func (l *rateLimiter) block(method string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[method]
+	if !ok {
+		b = &bucket{last: l.now()}
+		l.buckets[method] = b
+	}
+	b.blockedUntil = l.now().Add(d)
+}
+
+// wait blocks until a request for method may be made, or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, method string, tier RateTier) error {
+	return sleep(ctx, l.reserve(method, tier))
+}
+
+// sleep waits for d, or returns early with ctx's error.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a rateLimiter on a fake clock, and a func to move
+// the clock forward.
+func newTestLimiter() (*rateLimiter, func(d time.Duration)) {
+	l := newRateLimiter()
+	now := time.Date(2026, 10, 6, 8, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+// A minute's worth of requests go straight through, the rest are spaced
+// out evenly.
+func TestRateLimiterBurst(t *testing.T) {
+	l, advance := newTestLimiter()
+	for i := 0; i < Tier2.PerMinute(); i++ {
+		if wait := l.reserve("users.list", Tier2); wait != 0 {
+			t.Fatalf("Request %d waited %v", i, wait)
+		}
+	}
+	if wait := l.reserve("users.list", Tier2); wait != 3*time.Second {
+		t.Errorf("Expected to wait 3s past the burst, got %v", wait)
+	}
+	if wait := l.reserve("users.list", Tier2); wait != 6*time.Second {
+		t.Errorf("Expected to wait 6s for the next one, got %v", wait)
+	}
+
+	// Tokens come back over time.
+	advance(time.Minute)
+	if wait := l.reserve("users.list", Tier2); wait != 0 {
+		t.Errorf("Expected no wait a minute later, got %v", wait)
+	}
+}
+
+// Each method has its own bucket.
+func TestRateLimiterPerMethod(t *testing.T) {
+	l, _ := newTestLimiter()
+	l.reserve("conversations.create", Tier1)
+	if wait := l.reserve("conversations.create", Tier1); wait != time.Minute {
+		t.Errorf("Expected to wait a minute, got %v", wait)
+	}
+	if wait := l.reserve("conversations.invite", Tier1); wait != 0 {
+		t.Errorf("Expected another method not to wait, got %v", wait)
+	}
+}
+
+// After a 429, the method waits for Retry-After even with tokens left.
+func TestRateLimiterBlock(t *testing.T) {
+	l, advance := newTestLimiter()
+	l.reserve("chat.postMessage", TierSpecial)
+	l.block("chat.postMessage", 30*time.Second)
+
+	advance(10 * time.Second)
+	if wait := l.reserve("chat.postMessage", TierSpecial); wait != 20*time.Second {
+		t.Errorf("Expected to wait out the block, got %v", wait)
+	}
+	if wait := l.reserve("users.list", Tier2); wait != 0 {
+		t.Errorf("Expected another method not to be blocked, got %v", wait)
+	}
+
+	advance(20 * time.Second)
+	if wait := l.reserve("chat.postMessage", TierSpecial); wait != 0 {
+		t.Errorf("Expected no wait once the block passed, got %v", wait)
+	}
+}
+
+// Waiting stops when the context is cancelled.
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l, _ := newTestLimiter()
+	l.block("users.list", time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, "users.list", Tier2); err != context.Canceled {
+		t.Errorf("Expected the wait to be cancelled, got %v", err)
+	}
+}
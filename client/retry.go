@@ -0,0 +1,87 @@
+// Retries for transient failures talking to Slack.
+
+package client
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+// Requests are only retried when that's safe: idempotent (GET) requests
+// after network errors, 5xx responses and transient Slack errors, and any
+// request after errors Slack reports before doing anything.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// 1 or less disables retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles with
+	// every further retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries.
+	MaxBackoff time.Duration
+	// Jitter is the fraction (0 to 1) of each wait that is randomized,
+	// so concurrent callers don't retry in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by clients unless WithRetryPolicy is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Jitter:         0.2,
+}
+
+// NoRetries disables retrying failed requests.
+var NoRetries = RetryPolicy{MaxAttempts: 1}
+
+// backoff returns the wait before the given retry, starting at 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// Slack errors returned before the method did anything, so any request
+// may be retried.
+var safeToRetryErrors = map[string]bool{
+	"service_unavailable": true,
+	"request_timeout":     true,
+}
+
+// Slack errors where part of the operation may have happened, so only
+// idempotent requests may be retried.
+var transientErrors = map[string]bool{
+	"internal_error": true,
+	"fatal_error":    true,
+}
+
+// isRetryable decides whether a request may be retried, given the result
+// of the last attempt.
+func isRetryable(req Request, err error) bool {
+	idempotent := req.Verb() == "GET"
+
+	var transportErr *transportError
+	var statusErr *StatusError
+	var slackErr *SlackError
+	switch {
+	case errors.As(err, &transportErr):
+		return idempotent
+	case errors.As(err, &statusErr):
+		return idempotent && statusErr.StatusCode >= 500
+	case errors.As(err, &slackErr):
+		return safeToRetryErrors[slackErr.Code] ||
+			(idempotent && transientErrors[slackErr.Code])
+	}
+	return false
+}
@@ -0,0 +1,1328 @@
+// Package slacktest provides an in-memory fake of the Slack Web API, for
+// end-to-end tests of code using the client package.
+//
+//	srv := slacktest.NewServer()
+//	defer srv.Close()
+//	srv.AddUser(client.User{Id: "U1", Name: "alice"})
+//	c := client.NewClient("xoxb-test", client.WithBaseURL(srv.URL()))
+package slacktest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// Channel is the state of a fake channel.
+type Channel struct {
+	Id       string
+	Name     string
+	Topic    string
+	Purpose  string
+	Private  bool
+	Archived bool
+	Created  time.Time
+	Creator  string
+	// Members lists user IDs, in the order they joined.
+	Members []string
+}
+
+// Message is a message posted to a fake channel.
+type Message struct {
+	ChannelId string
+	Ts        string
+	User      string
+	// Subtype is set on system messages, e.g. "channel_join".
+	Subtype string
+	Text    string
+	Blocks  client.Blocks
+	// ThreadTs is the Ts of the thread's parent, for replies.
+	ThreadTs  string
+	Pinned    bool
+	Reactions []client.Reaction
+	// Edited is set once the message is updated.
+	Edited bool
+}
+
+func (m Message) isReply() bool {
+	return len(m.ThreadTs) > 0 && m.ThreadTs != m.Ts
+}
+
+// ScheduledMessage is a message waiting to be posted to a fake channel. It's
+// posted by the first request made at or after PostAt.
+type ScheduledMessage struct {
+	Id        string
+	ChannelId string
+	PostAt    time.Time
+	Created   time.Time
+	Text      string
+	Blocks    client.Blocks
+	ThreadTs  string
+}
+
+// Server is a stateful fake Slack Web API, served by an httptest.Server.
+// It is safe for concurrent use.
+type Server struct {
+	// PageSize is the page size of list methods, when requests set no limit.
+	PageSize int
+	// Token, if set, is the only bearer token accepted.
+	Token string
+	// Now returns the current time, used for timestamps. Defaults to time.Now.
+	Now func() time.Time
+	// BotUserId is the user making the API calls. It's added as a bot user,
+	// and joins the channels it creates.
+	BotUserId string
+
+	server *httptest.Server
+
+	mu        sync.Mutex
+	nextId    int
+	channels  []*Channel
+	users     []client.User
+	messages  map[string][]Message
+	scheduled []*ScheduledMessage
+	calls     []*client.Call
+	bookmarks []*client.Bookmark
+}
+
+// NewServer starts a fake Slack API. Callers must Close it.
+func NewServer() *Server {
+	s := &Server{
+		PageSize:  100,
+		Now:       time.Now,
+		BotUserId: "UBOT",
+		messages:  make(map[string][]Message),
+	}
+	s.users = append(s.users, client.User{Id: s.BotUserId, Name: "janitor", IsBot: true})
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// URL is the base URL of the fake API, for client.WithBaseURL.
+func (s *Server) URL() string {
+	return s.server.URL + "/api/"
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// AddUser adds a workspace member.
+func (s *Server) AddUser(user client.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, user)
+}
+
+// AddChannel adds a public channel with the given members, and returns its ID.
+func (s *Server) AddChannel(name string, members ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.newChannel(name, "")
+	c.Members = append(c.Members, members...)
+	return c.Id
+}
+
+// ArchiveChannel archives the channel with the given ID, e.g. to set up a
+// channel archived by someone else.
+func (s *Server) ArchiveChannel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, _ := s.findChannel(id); c != nil {
+		c.Archived = true
+	}
+}
+
+// Channel returns a copy of the channel with the given name.
+func (s *Server) Channel(name string) (Channel, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.channels {
+		if c.Name == name {
+			snapshot := *c
+			snapshot.Members = append([]string(nil), c.Members...)
+			return snapshot, true
+		}
+	}
+	return Channel{}, false
+}
+
+// Channels returns copies of all channels, in creation order.
+func (s *Server) Channels() []Channel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	channels := make([]Channel, 0, len(s.channels))
+	for _, c := range s.channels {
+		snapshot := *c
+		snapshot.Members = append([]string(nil), c.Members...)
+		channels = append(channels, snapshot)
+	}
+	return channels
+}
+
+// Messages returns the messages posted to a channel, oldest first,
+// including the scheduled messages that are due.
+func (s *Server) Messages(channelId string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.postScheduled()
+	return append([]Message(nil), s.messages[channelId]...)
+}
+
+// AddMessage posts msg to its channel, as if a user did. Ts is set if
+// empty. Returns the message as posted.
+func (s *Server) AddMessage(msg Message) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(msg.Ts) == 0 {
+		msg.Ts = s.ts()
+	}
+	s.messages[msg.ChannelId] = append(s.messages[msg.ChannelId], msg)
+	return msg
+}
+
+// ScheduledMessages returns the messages waiting to be posted to a channel,
+// in the order they were scheduled.
+func (s *Server) ScheduledMessages(channelId string) []ScheduledMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.postScheduled()
+	var scheduled []ScheduledMessage
+	for _, m := range s.scheduled {
+		if m.ChannelId == channelId {
+			scheduled = append(scheduled, *m)
+		}
+	}
+	return scheduled
+}
+
+// Bookmarks returns the bookmarks of a channel, in the order they were
+// added.
+func (s *Server) Bookmarks(channelId string) []client.Bookmark {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var bookmarks []client.Bookmark
+	for _, b := range s.bookmarks {
+		if b.ChannelId == channelId {
+			bookmarks = append(bookmarks, *b)
+		}
+	}
+	return bookmarks
+}
+
+// Calls returns all calls added, in order.
+func (s *Server) Calls() []client.Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([]client.Call, 0, len(s.calls))
+	for _, c := range s.calls {
+		calls = append(calls, *c)
+	}
+	return calls
+}
+
+// handlerFunc implements an API method. It returns the response to encode,
+// or a Slack error code.
+type handlerFunc func(s *Server, r *http.Request) (interface{}, string)
+
+var methods = map[string]handlerFunc{
+	"auth.test":                   (*Server).authTest,
+	"bookmarks.add":               (*Server).bookmarksAdd,
+	"bookmarks.edit":              (*Server).bookmarksEdit,
+	"bookmarks.list":              (*Server).bookmarksList,
+	"bookmarks.remove":            (*Server).bookmarksRemove,
+	"calls.add":                   (*Server).callsAdd,
+	"calls.end":                   (*Server).callsEnd,
+	"calls.info":                  (*Server).callsInfo,
+	"calls.participants.add":      (*Server).callsParticipantsAdd,
+	"calls.participants.remove":   (*Server).callsParticipantsRemove,
+	"calls.update":                (*Server).callsUpdate,
+	"chat.deleteScheduledMessage": (*Server).chatDeleteScheduledMessage,
+	"chat.delete":                 (*Server).chatDelete,
+	"chat.postMessage":            (*Server).chatPostMessage,
+	"chat.scheduleMessage":        (*Server).chatScheduleMessage,
+	"chat.scheduledMessages.list": (*Server).chatScheduledMessagesList,
+	"chat.update":                 (*Server).chatUpdate,
+	"conversations.archive":       (*Server).conversationsArchive,
+	"conversations.create":        (*Server).conversationsCreate,
+	"conversations.history":       (*Server).conversationsHistory,
+	"conversations.info":          (*Server).conversationsInfo,
+	"conversations.invite":        (*Server).conversationsInvite,
+	"conversations.list":          (*Server).conversationsList,
+	"conversations.members":       (*Server).conversationsMembers,
+	"conversations.replies":       (*Server).conversationsReplies,
+	"conversations.setPurpose":    (*Server).conversationsSetPurpose,
+	"conversations.setTopic":      (*Server).conversationsSetTopic,
+	"conversations.unarchive":     (*Server).conversationsUnarchive,
+	"pins.add":                    (*Server).pinsAdd,
+	"pins.remove":                 (*Server).pinsRemove,
+	"reactions.add":               (*Server).reactionsAdd,
+	"reactions.get":               (*Server).reactionsGet,
+	"users.info":                  (*Server).usersInfo,
+	"users.list":                  (*Server).usersList,
+	"users.lookupByEmail":         (*Server).usersLookupByEmail,
+}
+
+// ServeHTTP dispatches /api/<method> requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	handler, ok := methods[method]
+	if !ok {
+		writeError(w, "unknown_method")
+		return
+	}
+
+	auth := r.Header.Get("Authorization")
+	if len(auth) == 0 {
+		writeError(w, "not_authed")
+		return
+	}
+	if len(s.Token) > 0 && auth != "Bearer "+s.Token {
+		writeError(w, "invalid_auth")
+		return
+	}
+
+	s.mu.Lock()
+	s.postScheduled()
+	resp, code := handler(s, r)
+	s.mu.Unlock()
+
+	if len(code) > 0 {
+		log.Printf("slacktest: %s failed with %s", method, code)
+		writeError(w, code)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, code string) {
+	json.NewEncoder(w).Encode(client.SlackResponse{Ok: false, Error: code})
+}
+
+// decode reads a JSON request body into req.
+func decode(r *http.Request, req interface{}) string {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return "invalid_json"
+	}
+	return ""
+}
+
+var okResponse = client.SlackResponse{Ok: true}
+
+// page returns the [start, end) range of a list of total items for the
+// request's cursor and limit, and the cursor of the next page.
+func (s *Server) page(r *http.Request, total int) (start, end int, next string, code string) {
+	limit := s.PageSize
+	if l := r.URL.Query().Get("limit"); len(l) > 0 {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+			return 0, 0, "", "invalid_limit"
+		}
+	}
+	if limit == 0 {
+		limit = total
+	}
+
+	if cursor := r.URL.Query().Get("cursor"); len(cursor) > 0 {
+		decoded, err := base64.StdEncoding.DecodeString(cursor)
+		if err != nil || !strings.HasPrefix(string(decoded), "offset:") {
+			return 0, 0, "", "invalid_cursor"
+		}
+		if start, err = strconv.Atoi(strings.TrimPrefix(string(decoded), "offset:")); err != nil {
+			return 0, 0, "", "invalid_cursor"
+		}
+	}
+
+	if start > total {
+		start = total
+	}
+	end = start + limit
+	if end >= total {
+		end = total
+	} else {
+		next = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("offset:%d", end)))
+	}
+	return start, end, next, ""
+}
+
+func (s *Server) newId(prefix string) string {
+	s.nextId++
+	return fmt.Sprintf("%s%08X", prefix, s.nextId)
+}
+
+// ts returns a unique message timestamp.
+func (s *Server) ts() string {
+	s.nextId++
+	return fmt.Sprintf("%d.%06d", s.Now().Unix(), s.nextId)
+}
+
+func (s *Server) newChannel(name string, creator string) *Channel {
+	c := &Channel{
+		Id:      s.newId("C"),
+		Name:    name,
+		Created: s.Now(),
+		Creator: creator,
+	}
+	s.channels = append(s.channels, c)
+	return c
+}
+
+func (s *Server) findChannel(id string) (*Channel, string) {
+	for _, c := range s.channels {
+		if c.Id == id {
+			return c, ""
+		}
+	}
+	return nil, "channel_not_found"
+}
+
+func (s *Server) findUser(id string) *client.User {
+	for i := range s.users {
+		if s.users[i].Id == id {
+			return &s.users[i]
+		}
+	}
+	return nil
+}
+
+func (c *Channel) isMember(user string) bool {
+	return contains(c.Members, user)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Channel) toClient() client.Channel {
+	return client.Channel{
+		Id:         c.Id,
+		Name:       c.Name,
+		Created:    c.Created.Unix(),
+		Creator:    c.Creator,
+		IsArchived: c.Archived,
+		IsPrivate:  c.Private,
+		NumMembers: len(c.Members),
+		Topic:      client.ChannelText{Value: c.Topic},
+		Purpose:    client.ChannelText{Value: c.Purpose},
+	}
+}
+
+var validChannelName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+func (s *Server) conversationsCreate(r *http.Request) (interface{}, string) {
+	var req client.CreateChannelRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	switch {
+	case len(req.Name) == 0:
+		return nil, "invalid_name_required"
+	case len(req.Name) > 80:
+		return nil, "invalid_name_maxlength"
+	case !validChannelName.MatchString(req.Name):
+		return nil, "invalid_name_specials"
+	}
+	for _, c := range s.channels {
+		if c.Name == req.Name {
+			return nil, "name_taken"
+		}
+	}
+
+	c := s.newChannel(req.Name, s.BotUserId)
+	c.Private = req.IsPrivate
+	c.Members = append(c.Members, s.BotUserId)
+	return client.ChannelResponse{SlackResponse: okResponse, Channel: c.toClient()}, ""
+}
+
+func (s *Server) conversationsList(r *http.Request) (interface{}, string) {
+	excludeArchived := r.URL.Query().Get("exclude_archived") == "true"
+	types := map[string]bool{client.PublicChannel: true}
+	if t := r.URL.Query().Get("types"); len(t) > 0 {
+		types = map[string]bool{}
+		for _, channelType := range strings.Split(t, ",") {
+			types[channelType] = true
+		}
+	}
+
+	var matching []*Channel
+	for _, c := range s.channels {
+		if excludeArchived && c.Archived {
+			continue
+		}
+		if c.Private && (!types[client.PrivateChannel] || !c.isMember(s.BotUserId)) {
+			// Private channels are only visible to their members.
+			continue
+		}
+		if !c.Private && !types[client.PublicChannel] {
+			continue
+		}
+		matching = append(matching, c)
+	}
+
+	start, end, next, code := s.page(r, len(matching))
+	if len(code) > 0 {
+		return nil, code
+	}
+	resp := client.ChannelListResponse{SlackResponse: okResponse, Channels: []client.Channel{}}
+	resp.Metadata.NextCursor = next
+	for _, c := range matching[start:end] {
+		resp.Channels = append(resp.Channels, c.toClient())
+	}
+	return resp, ""
+}
+
+func (s *Server) conversationsInvite(r *http.Request) (interface{}, string) {
+	var req client.ConversationInvite
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if c.Archived {
+		return nil, "is_archived"
+	}
+	if len(req.Users) == 0 {
+		return nil, "no_user"
+	}
+
+	var valid []string
+	var errs []client.InviteError
+	for _, user := range req.Users {
+		code := ""
+		switch {
+		case user == s.BotUserId:
+			code = "cant_invite_self"
+		case s.findUser(user) == nil:
+			code = "user_not_found"
+		case c.isMember(user) || contains(valid, user):
+			code = "already_in_channel"
+		}
+		if len(code) > 0 {
+			errs = append(errs, client.InviteError{User: user, Error: code})
+		} else {
+			valid = append(valid, user)
+		}
+	}
+
+	// Like Slack, fail the whole request if any user can't be invited,
+	// unless forced to invite the valid ones.
+	if len(errs) > 0 && (!req.Force || len(valid) == 0) {
+		return client.InviteResponse{
+			SlackResponse: client.SlackResponse{Error: errs[0].Error},
+			Errors:        errs,
+		}, ""
+	}
+	c.Members = append(c.Members, valid...)
+	for _, user := range valid {
+		s.postSystemMessage(c, user, "channel_join", fmt.Sprintf("<@%s> has joined the channel", user))
+	}
+	return client.InviteResponse{SlackResponse: okResponse, Channel: c.toClient(), Errors: errs}, ""
+}
+
+func (s *Server) conversationsMembers(r *http.Request) (interface{}, string) {
+	c, code := s.findChannel(r.URL.Query().Get("channel"))
+	if c == nil {
+		return nil, code
+	}
+	start, end, next, code := s.page(r, len(c.Members))
+	if len(code) > 0 {
+		return nil, code
+	}
+	resp := client.ConversationMembersResponse{SlackResponse: okResponse, Members: []string{}}
+	resp.Metadata.NextCursor = next
+	resp.Members = append(resp.Members, c.Members[start:end]...)
+	return resp, ""
+}
+
+func (s *Server) conversationsSetTopic(r *http.Request) (interface{}, string) {
+	var req client.ChannelSetTopicRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if c.Archived {
+		return nil, "is_archived"
+	}
+	if len(req.Topic) > 250 {
+		return nil, "too_long"
+	}
+	c.Topic = req.Topic
+	s.postSystemMessage(c, s.BotUserId, "channel_topic",
+		fmt.Sprintf("<@%s> set the channel topic: %s", s.BotUserId, req.Topic))
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+// postSystemMessage posts a message like the ones Slack posts when e.g.
+// someone joins the channel.
+func (s *Server) postSystemMessage(c *Channel, user string, subtype string, text string) {
+	s.messages[c.Id] = append(s.messages[c.Id], Message{
+		ChannelId: c.Id,
+		Ts:        s.ts(),
+		User:      user,
+		Subtype:   subtype,
+		Text:      text,
+	})
+}
+
+func (s *Server) conversationsInfo(r *http.Request) (interface{}, string) {
+	c, code := s.findChannel(r.URL.Query().Get("channel"))
+	if c == nil {
+		return nil, code
+	}
+	channel := c.toClient()
+	if r.URL.Query().Get("include_num_members") != "true" {
+		channel.NumMembers = 0
+	}
+	return client.ChannelResponse{SlackResponse: okResponse, Channel: channel}, ""
+}
+
+func (s *Server) authTest(r *http.Request) (interface{}, string) {
+	resp := client.AuthTestResponse{
+		SlackResponse: okResponse,
+		Url:           "https://slacktest.slack.com/",
+		Team:          "slacktest",
+		TeamId:        "T00000001",
+		UserId:        s.BotUserId,
+		BotId:         s.botId(),
+	}
+	if user := s.findUser(s.BotUserId); user != nil {
+		resp.User = user.Name
+	}
+	return resp, ""
+}
+
+// botId is the bot ID of BotUserId.
+func (s *Server) botId() string {
+	return "B" + strings.TrimPrefix(s.BotUserId, "U")
+}
+
+func (s *Server) conversationsSetPurpose(r *http.Request) (interface{}, string) {
+	var req client.ChannelSetPurposeRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if c.Archived {
+		return nil, "is_archived"
+	}
+	if len(req.Purpose) > 250 {
+		return nil, "too_long"
+	}
+	c.Purpose = req.Purpose
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) conversationsArchive(r *http.Request) (interface{}, string) {
+	var req client.ChannelArchiveRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if c.Archived {
+		return nil, "already_archived"
+	}
+	c.Archived = true
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+// conversationsUnarchive only lets members unarchive a channel, like Slack
+// does for bots.
+func (s *Server) conversationsUnarchive(r *http.Request) (interface{}, string) {
+	var req client.ChannelUnarchiveRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if !c.Archived {
+		return nil, "not_archived"
+	}
+	if !contains(c.Members, s.BotUserId) {
+		return nil, "not_in_channel"
+	}
+	c.Archived = false
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) usersList(r *http.Request) (interface{}, string) {
+	start, end, next, code := s.page(r, len(s.users))
+	if len(code) > 0 {
+		return nil, code
+	}
+	resp := client.UsersListResponse{SlackResponse: okResponse, Members: []client.User{}}
+	resp.Metadata.NextCursor = next
+	resp.Members = append(resp.Members, s.users[start:end]...)
+	return resp, ""
+}
+
+func (s *Server) usersInfo(r *http.Request) (interface{}, string) {
+	user := s.findUser(r.URL.Query().Get("user"))
+	if user == nil {
+		return nil, "user_not_found"
+	}
+	return client.UserResponse{SlackResponse: okResponse, User: *user}, ""
+}
+
+func (s *Server) usersLookupByEmail(r *http.Request) (interface{}, string) {
+	email := r.URL.Query().Get("email")
+	for _, user := range s.users {
+		if len(email) > 0 && strings.EqualFold(user.Profile.Email, email) {
+			return client.UserResponse{SlackResponse: okResponse, User: user}, ""
+		}
+	}
+	return nil, "users_not_found"
+}
+
+var (
+	urlPattern       = regexp.MustCompile(`https?://[^\s<>]+`)
+	formattedPattern = regexp.MustCompile(`<(?:https?://|mailto:|@|#|!)[^<>]*>`)
+)
+
+// normalizeText rewrites a message's text like Slack does when it's
+// posted: &, < and > are escaped, and links are wrapped in <>. Links,
+// mentions and the like that are already formatted are kept as they are.
+func normalizeText(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range formattedPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(urlPattern.ReplaceAllString(client.EscapeText(text[last:loc[0]]), "<$0>"))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(urlPattern.ReplaceAllString(client.EscapeText(text[last:]), "<$0>"))
+	return b.String()
+}
+
+// postScheduled posts the scheduled messages that are due.
+func (s *Server) postScheduled() {
+	now := s.Now()
+	waiting := s.scheduled[:0]
+	for _, m := range s.scheduled {
+		if now.Before(m.PostAt) {
+			waiting = append(waiting, m)
+			continue
+		}
+		s.messages[m.ChannelId] = append(s.messages[m.ChannelId], Message{
+			ChannelId: m.ChannelId,
+			Ts:        s.ts(),
+			User:      s.BotUserId,
+			Text:      m.Text,
+			Blocks:    m.Blocks,
+			ThreadTs:  m.ThreadTs,
+		})
+	}
+	s.scheduled = waiting
+}
+
+func (s *Server) chatScheduleMessage(r *http.Request) (interface{}, string) {
+	var req client.ScheduleMessageRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if c.Archived {
+		return nil, "is_archived"
+	}
+	if len(req.Text) == 0 && len(req.Blocks) == 0 {
+		return nil, "no_text"
+	}
+	if client.ValidateBlocks(req.Blocks) != nil {
+		return nil, "invalid_blocks"
+	}
+	postAt := time.Unix(req.PostAt, 0)
+	if !postAt.After(s.Now()) {
+		return nil, "time_in_past"
+	}
+	if postAt.After(s.Now().AddDate(0, 0, 120)) {
+		return nil, "time_too_far"
+	}
+
+	m := &ScheduledMessage{
+		Id:        s.newId("Q"),
+		ChannelId: c.Id,
+		PostAt:    postAt,
+		Created:   s.Now(),
+		Text:      normalizeText(req.Text),
+		Blocks:    req.Blocks,
+		ThreadTs:  req.ThreadTs,
+	}
+	s.scheduled = append(s.scheduled, m)
+	return client.ScheduleMessageResponse{
+		SlackResponse:      okResponse,
+		Channel:            c.Id,
+		ScheduledMessageId: m.Id,
+		PostAt:             req.PostAt,
+		Message: client.Message{
+			Type: "message", User: s.BotUserId, BotId: s.botId(), Text: m.Text, Blocks: m.Blocks},
+	}, ""
+}
+
+// chatScheduledMessagesList returns every match on one page.
+func (s *Server) chatScheduledMessagesList(r *http.Request) (interface{}, string) {
+	var req client.ScheduledMessagesListRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	resp := client.ScheduledMessagesListResponse{
+		SlackResponse: okResponse, ScheduledMessages: []client.ScheduledMessage{}}
+	for _, m := range s.scheduled {
+		if len(req.ChannelId) > 0 && m.ChannelId != req.ChannelId {
+			continue
+		}
+		resp.ScheduledMessages = append(resp.ScheduledMessages, client.ScheduledMessage{
+			Id:          m.Id,
+			ChannelId:   m.ChannelId,
+			PostAt:      m.PostAt.Unix(),
+			DateCreated: m.Created.Unix(),
+			Text:        m.Text,
+		})
+	}
+	return resp, ""
+}
+
+func (s *Server) chatDeleteScheduledMessage(r *http.Request) (interface{}, string) {
+	var req client.DeleteScheduledMessageRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	if c, code := s.findChannel(req.ChannelId); c == nil {
+		return nil, code
+	}
+	for i, m := range s.scheduled {
+		if m.Id == req.ScheduledMessageId && m.ChannelId == req.ChannelId {
+			s.scheduled = append(s.scheduled[:i], s.scheduled[i+1:]...)
+			return client.GenericResponse{SlackResponse: okResponse}, ""
+		}
+	}
+	return nil, "invalid_scheduled_message_id"
+}
+
+func (s *Server) chatPostMessage(r *http.Request) (interface{}, string) {
+	var req client.PostMessageRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if c.Archived {
+		return nil, "is_archived"
+	}
+	if len(req.Text) == 0 && len(req.Blocks) == 0 {
+		return nil, "no_text"
+	}
+	if client.ValidateBlocks(req.Blocks) != nil {
+		return nil, "invalid_blocks"
+	}
+
+	msg := Message{
+		ChannelId: c.Id,
+		Ts:        s.ts(),
+		User:      s.BotUserId,
+		Text:      normalizeText(req.Text),
+		Blocks:    req.Blocks,
+		ThreadTs:  req.ThreadTs,
+	}
+	s.messages[c.Id] = append(s.messages[c.Id], msg)
+	return client.PostMessageResponse{
+		SlackResponse: okResponse,
+		Channel:       c.Id,
+		Ts:            msg.Ts,
+		Message:       s.toClientMessage(msg),
+	}, ""
+}
+
+// findMessage returns the message with the given ts in the channel.
+func (s *Server) findMessage(channelId string, ts string) (*Message, string) {
+	c, code := s.findChannel(channelId)
+	if c == nil {
+		return nil, code
+	}
+	messages := s.messages[c.Id]
+	for i := range messages {
+		if messages[i].Ts == ts {
+			return &messages[i], ""
+		}
+	}
+	return nil, "message_not_found"
+}
+
+func (s *Server) chatUpdate(r *http.Request) (interface{}, string) {
+	var req client.UpdateMessageRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	msg, code := s.findMessage(req.ChannelId, req.Ts)
+	if msg == nil {
+		return nil, code
+	}
+	if msg.User != s.BotUserId {
+		return nil, "cant_update_message"
+	}
+	if len(req.Text) == 0 && len(req.Blocks) == 0 {
+		return nil, "no_text"
+	}
+	if client.ValidateBlocks(req.Blocks) != nil {
+		return nil, "invalid_blocks"
+	}
+	msg.Text, msg.Blocks, msg.Edited = normalizeText(req.Text), req.Blocks, true
+	return client.PostMessageResponse{
+		SlackResponse: okResponse,
+		Channel:       msg.ChannelId,
+		Ts:            msg.Ts,
+		Message:       s.toClientMessage(*msg),
+	}, ""
+}
+
+func (s *Server) chatDelete(r *http.Request) (interface{}, string) {
+	var req client.DeleteMessageRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	msg, code := s.findMessage(req.ChannelId, req.Ts)
+	if msg == nil {
+		return nil, code
+	}
+	if msg.User != s.BotUserId {
+		return nil, "cant_delete_message"
+	}
+	messages := s.messages[msg.ChannelId]
+	for i := range messages {
+		if messages[i].Ts == req.Ts {
+			s.messages[msg.ChannelId] = append(messages[:i], messages[i+1:]...)
+			break
+		}
+	}
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) bookmarksAdd(r *http.Request) (interface{}, string) {
+	var req client.BookmarksAddRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	c, code := s.findChannel(req.ChannelId)
+	if c == nil {
+		return nil, code
+	}
+	if c.Archived {
+		return nil, "is_archived"
+	}
+	if req.Type != client.BookmarkLink {
+		return nil, "invalid_bookmark_type"
+	}
+	if len(req.Title) == 0 || len(req.Link) == 0 {
+		return nil, "invalid_arguments"
+	}
+	b := &client.Bookmark{
+		Id:                  s.newId("Bk"),
+		ChannelId:           c.Id,
+		Title:               req.Title,
+		Link:                req.Link,
+		Emoji:               req.Emoji,
+		Type:                req.Type,
+		DateCreated:         s.Now().Unix(),
+		DateUpdated:         s.Now().Unix(),
+		LastUpdatedByUserId: s.BotUserId,
+	}
+	s.bookmarks = append(s.bookmarks, b)
+	return client.BookmarkResponse{SlackResponse: okResponse, Bookmark: *b}, ""
+}
+
+// findBookmark returns the index of the bookmark in the channel.
+func (s *Server) findBookmark(channelId string, id string) (int, string) {
+	if c, code := s.findChannel(channelId); c == nil {
+		return -1, code
+	}
+	for i, b := range s.bookmarks {
+		if b.Id == id && b.ChannelId == channelId {
+			return i, ""
+		}
+	}
+	return -1, "not_found"
+}
+
+func (s *Server) bookmarksEdit(r *http.Request) (interface{}, string) {
+	var req client.BookmarksEditRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	i, code := s.findBookmark(req.ChannelId, req.BookmarkId)
+	if i < 0 {
+		return nil, code
+	}
+	b := s.bookmarks[i]
+	if len(req.Title) > 0 {
+		b.Title = req.Title
+	}
+	if len(req.Link) > 0 {
+		b.Link = req.Link
+	}
+	if len(req.Emoji) > 0 {
+		b.Emoji = req.Emoji
+	}
+	b.DateUpdated = s.Now().Unix()
+	b.LastUpdatedByUserId = s.BotUserId
+	return client.BookmarkResponse{SlackResponse: okResponse, Bookmark: *b}, ""
+}
+
+func (s *Server) bookmarksRemove(r *http.Request) (interface{}, string) {
+	var req client.BookmarksRemoveRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	i, code := s.findBookmark(req.ChannelId, req.BookmarkId)
+	if i < 0 {
+		return nil, code
+	}
+	s.bookmarks = append(s.bookmarks[:i], s.bookmarks[i+1:]...)
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) bookmarksList(r *http.Request) (interface{}, string) {
+	var req client.BookmarksListRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	if c, code := s.findChannel(req.ChannelId); c == nil {
+		return nil, code
+	}
+	resp := client.BookmarksListResponse{SlackResponse: okResponse, Bookmarks: []client.Bookmark{}}
+	for _, b := range s.bookmarks {
+		if b.ChannelId == req.ChannelId {
+			resp.Bookmarks = append(resp.Bookmarks, *b)
+		}
+	}
+	return resp, ""
+}
+
+func (s *Server) pinsAdd(r *http.Request) (interface{}, string) {
+	var req client.PinsAddRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	msg, code := s.findMessage(req.ChannelId, req.Timestamp)
+	if msg == nil {
+		return nil, code
+	}
+	if msg.Pinned {
+		return nil, "already_pinned"
+	}
+	msg.Pinned = true
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) pinsRemove(r *http.Request) (interface{}, string) {
+	var req client.PinsRemoveRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	msg, code := s.findMessage(req.ChannelId, req.Timestamp)
+	if msg == nil {
+		return nil, code
+	}
+	if !msg.Pinned {
+		return nil, "no_pin"
+	}
+	msg.Pinned = false
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) reactionsAdd(r *http.Request) (interface{}, string) {
+	var req client.ReactionsAddRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	msg, code := s.findMessage(req.ChannelId, req.Timestamp)
+	if msg == nil {
+		return nil, code
+	}
+	if len(req.Name) == 0 {
+		return nil, "invalid_name"
+	}
+	for i := range msg.Reactions {
+		reaction := &msg.Reactions[i]
+		if reaction.Name != req.Name {
+			continue
+		}
+		if contains(reaction.Users, s.BotUserId) {
+			return nil, "already_reacted"
+		}
+		reaction.Count++
+		reaction.Users = append(reaction.Users, s.BotUserId)
+		return client.GenericResponse{SlackResponse: okResponse}, ""
+	}
+	msg.Reactions = append(msg.Reactions,
+		client.Reaction{Name: req.Name, Count: 1, Users: []string{s.BotUserId}})
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) reactionsGet(r *http.Request) (interface{}, string) {
+	query := r.URL.Query()
+	msg, code := s.findMessage(query.Get("channel"), query.Get("timestamp"))
+	if msg == nil {
+		return nil, code
+	}
+	return client.ReactionsGetResponse{
+		SlackResponse: okResponse,
+		Type:          "message",
+		Channel:       msg.ChannelId,
+		Message:       s.toClientMessage(*msg),
+	}, ""
+}
+
+// toClientMessage converts msg, counting its replies if it's a thread
+// parent.
+func (s *Server) toClientMessage(msg Message) client.Message {
+	m := client.Message{
+		Type:     "message",
+		Subtype:  msg.Subtype,
+		User:     msg.User,
+		Text:     msg.Text,
+		Ts:       msg.Ts,
+		ThreadTs: msg.ThreadTs,
+		Blocks:   msg.Blocks,
+	}
+	if msg.User == s.BotUserId {
+		m.BotId = s.botId()
+	}
+	if msg.Pinned {
+		m.PinnedTo = []string{msg.ChannelId}
+	}
+	m.Reactions = append(m.Reactions, msg.Reactions...)
+	for _, reply := range s.messages[msg.ChannelId] {
+		if reply.isReply() && reply.ThreadTs == msg.Ts {
+			m.ThreadTs = msg.Ts
+			m.ReplyCount++
+			m.LatestReply = reply.Ts
+			if !contains(m.ReplyUsers, reply.User) {
+				m.ReplyUsers = append(m.ReplyUsers, reply.User)
+			}
+		}
+	}
+	return m
+}
+
+// inRange checks ts against the request's oldest, latest and inclusive
+// parameters.
+func inRange(r *http.Request, ts string) bool {
+	query := r.URL.Query()
+	t, _ := client.ParseTs(ts)
+	inclusive := query.Get("inclusive") == "true"
+	if oldest := query.Get("oldest"); len(oldest) > 0 {
+		o, _ := client.ParseTs(oldest)
+		if t.Before(o) || (t.Equal(o) && !inclusive) {
+			return false
+		}
+	}
+	if latest := query.Get("latest"); len(latest) > 0 {
+		l, _ := client.ParseTs(latest)
+		if t.After(l) || (t.Equal(l) && !inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// messagesPage returns a page of messages.
+func (s *Server) messagesPage(r *http.Request, matching []Message) (interface{}, string) {
+	start, end, next, code := s.page(r, len(matching))
+	if len(code) > 0 {
+		return nil, code
+	}
+	resp := client.MessagesResponse{SlackResponse: okResponse, Messages: []client.Message{}}
+	resp.Metadata.NextCursor = next
+	resp.HasMore = len(next) > 0
+	for _, msg := range matching[start:end] {
+		resp.Messages = append(resp.Messages, s.toClientMessage(msg))
+	}
+	return resp, ""
+}
+
+func (s *Server) conversationsHistory(r *http.Request) (interface{}, string) {
+	c, code := s.findChannel(r.URL.Query().Get("channel"))
+	if c == nil {
+		return nil, code
+	}
+
+	// Newest first, without replies.
+	var matching []Message
+	messages := s.messages[c.Id]
+	for i := len(messages) - 1; i >= 0; i-- {
+		if !messages[i].isReply() && inRange(r, messages[i].Ts) {
+			matching = append(matching, messages[i])
+		}
+	}
+	return s.messagesPage(r, matching)
+}
+
+func (s *Server) conversationsReplies(r *http.Request) (interface{}, string) {
+	c, code := s.findChannel(r.URL.Query().Get("channel"))
+	if c == nil {
+		return nil, code
+	}
+	ts := r.URL.Query().Get("ts")
+
+	// The parent, then its replies, oldest first.
+	var matching []Message
+	found := false
+	for _, msg := range s.messages[c.Id] {
+		if msg.Ts == ts {
+			found = true
+		}
+		if (msg.Ts == ts || (msg.isReply() && msg.ThreadTs == ts)) && inRange(r, msg.Ts) {
+			matching = append(matching, msg)
+		}
+	}
+	if !found {
+		return nil, "thread_not_found"
+	}
+	return s.messagesPage(r, matching)
+}
+
+func (s *Server) callsAdd(r *http.Request) (interface{}, string) {
+	var req client.Call
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	if len(req.ExternalUniqueId) == 0 || len(req.JoinUrl) == 0 {
+		return nil, "invalid_arguments"
+	}
+
+	call := req
+	call.Id = s.newId("R")
+	s.calls = append(s.calls, &call)
+	return client.CallResponse{SlackResponse: okResponse, Call: call}, ""
+}
+
+func (s *Server) findCall(id string) *client.Call {
+	for _, call := range s.calls {
+		if call.Id == id {
+			return call
+		}
+	}
+	return nil
+}
+
+func (s *Server) callsEnd(r *http.Request) (interface{}, string) {
+	var req client.CallEnd
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	call := s.findCall(req.Id)
+	if call == nil {
+		return nil, "not_found"
+	}
+	if call.DateEnd == 0 {
+		call.DateEnd = s.Now().Unix()
+	}
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) callsInfo(r *http.Request) (interface{}, string) {
+	call := s.findCall(r.URL.Query().Get("id"))
+	if call == nil {
+		return nil, "not_found"
+	}
+	return client.CallResponse{SlackResponse: okResponse, Call: *call}, ""
+}
+
+func (s *Server) callsUpdate(r *http.Request) (interface{}, string) {
+	var req client.CallUpdateRequest
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	call := s.findCall(req.Id)
+	if call == nil {
+		return nil, "not_found"
+	}
+	if len(req.Title) > 0 {
+		call.Title = req.Title
+	}
+	if len(req.JoinUrl) > 0 {
+		call.JoinUrl = req.JoinUrl
+	}
+	if len(req.DesktopAppJoinUrl) > 0 {
+		call.DesktopAppJoinUrl = req.DesktopAppJoinUrl
+	}
+	return client.CallResponse{SlackResponse: okResponse, Call: *call}, ""
+}
+
+func (s *Server) callsParticipantsAdd(r *http.Request) (interface{}, string) {
+	var req client.CallParticipantsAdd
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	call := s.findCall(req.Id)
+	if call == nil {
+		return nil, "not_found"
+	}
+	if len(req.Users) == 0 {
+		return nil, "invalid_users"
+	}
+	call.Users = append(call.Users, req.Users...)
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
+
+func (s *Server) callsParticipantsRemove(r *http.Request) (interface{}, string) {
+	var req client.CallParticipantsRemove
+	if code := decode(r, &req); len(code) > 0 {
+		return nil, code
+	}
+	call := s.findCall(req.Id)
+	if call == nil {
+		return nil, "not_found"
+	}
+	if len(req.Users) == 0 {
+		return nil, "invalid_users"
+	}
+	var remaining []client.CallUser
+	for _, user := range call.Users {
+		removed := false
+		for _, u := range req.Users {
+			if user == u {
+				removed = true
+			}
+		}
+		if !removed {
+			remaining = append(remaining, user)
+		}
+	}
+	call.Users = remaining
+	return client.GenericResponse{SlackResponse: okResponse}, ""
+}
@@ -0,0 +1,450 @@
+package slacktest_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+	"github.com/jaywhyzed/slackJanitor/client/slacktest"
+)
+
+func newClient(srv *slacktest.Server) client.Client {
+	return client.NewClient("xoxb-test",
+		client.WithBaseURL(srv.URL()),
+		client.WithRateLimits(false),
+		client.WithRetryPolicy(client.NoRetries))
+}
+
+// List methods paginate with the server's page size.
+func TestUsersListPagination(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+	for _, id := range []string{"U1", "U2", "U3"} {
+		srv.AddUser(client.User{Id: id, Name: id})
+	}
+
+	var ids []string
+	pages := 0
+	var resp client.UsersListResponse
+	err := client.Paginate(context.Background(), newClient(srv),
+		client.UsersListRequest{}, &resp, func() error {
+			pages++
+			for _, user := range resp.Members {
+				ids = append(ids, user.Id)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if expected := []string{"UBOT", "U1", "U2", "U3"}; !reflect.DeepEqual(expected, ids) {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+	if pages != 2 {
+		t.Errorf("Expected 2 pages, got %d", pages)
+	}
+}
+
+// Users can be looked up by ID or email.
+func TestUsersLookup(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	alice := client.User{Id: "U1", Name: "alice", Profile: client.UserProfile{Email: "alice@example.com"}}
+	srv.AddUser(alice)
+	c := newClient(srv)
+
+	var resp client.UserResponse
+	if _, err := c.Execute(client.UsersInfoRequest{UserId: "U1"}, &resp); err != nil || !reflect.DeepEqual(resp.User, alice) {
+		t.Errorf("Expected %+v, got %+v, %v", alice, resp.User, err)
+	}
+	if _, err := c.Execute(client.UsersInfoRequest{UserId: "U2"}, &resp); !client.IsSlackError(err, "user_not_found") {
+		t.Errorf("Expected user_not_found, got: %v", err)
+	}
+	if _, err := c.Execute(client.UsersLookupByEmailRequest{Email: "Alice@example.com"}, &resp); err != nil || resp.User.Id != "U1" {
+		t.Errorf("Expected U1, got %+v, %v", resp.User, err)
+	}
+	if _, err := c.Execute(client.UsersLookupByEmailRequest{Email: "bob@example.com"}, &resp); !client.IsSlackError(err, "users_not_found") {
+		t.Errorf("Expected users_not_found, got: %v", err)
+	}
+}
+
+// Channel methods keep state, and fail like Slack does.
+func TestChannelLifecycle(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	srv.AddUser(client.User{Id: "U1", Name: "alice"})
+	c := newClient(srv)
+
+	var channelResp client.ChannelResponse
+	if _, err := c.Execute(client.CreateChannelRequest{Name: "weekly"}, &channelResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	id := channelResp.Channel.Id
+
+	if _, err := c.Execute(client.CreateChannelRequest{Name: "weekly"}, &channelResp); !client.IsNameTaken(err) {
+		t.Errorf("Expected name_taken, got: %v", err)
+	}
+
+	invite := client.ConversationInvite{ChannelId: id, Users: []string{"U1"}}
+	if _, err := c.Execute(invite, &channelResp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(invite, &channelResp); !client.IsAlreadyInChannel(err) {
+		t.Errorf("Expected already_in_channel, got: %v", err)
+	}
+
+	var resp client.GenericResponse
+	if _, err := c.Execute(client.ChannelArchiveRequest{ChannelId: "CMISSING"}, &resp); !client.IsSlackError(err, "channel_not_found") {
+		t.Errorf("Expected channel_not_found, got: %v", err)
+	}
+	if _, err := c.Execute(client.ChannelArchiveRequest{ChannelId: id}, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+
+	channel, found := srv.Channel("weekly")
+	if !found || !channel.Archived || !reflect.DeepEqual(channel.Members, []string{"UBOT", "U1"}) {
+		t.Errorf("Unexpected channel state: %+v", channel)
+	}
+
+	var authResp client.AuthTestResponse
+	if _, err := c.Execute(client.AuthTestRequest{}, &authResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := c.Execute(client.ChannelInfoRequest{ChannelId: id, IncludeNumMembers: true}, &channelResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if info := channelResp.Channel; info.Creator != authResp.UserId || !info.IsArchived || info.NumMembers != 2 {
+		t.Errorf("Unexpected channel info: %+v", info)
+	}
+
+	if _, err := c.Execute(client.ChannelUnarchiveRequest{ChannelId: id}, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(client.ChannelUnarchiveRequest{ChannelId: id}, &resp); !client.IsSlackError(err, "not_archived") {
+		t.Errorf("Expected not_archived, got: %v", err)
+	}
+	if channel, _ := srv.Channel("weekly"); channel.Archived {
+		t.Errorf("Expected weekly to be unarchived: %+v", channel)
+	}
+
+	// The bot can't unarchive channels it isn't in.
+	otherId := srv.AddChannel("other", "U1")
+	srv.ArchiveChannel(otherId)
+	if _, err := c.Execute(client.ChannelUnarchiveRequest{ChannelId: otherId}, &resp); !client.IsSlackError(err, "not_in_channel") {
+		t.Errorf("Expected not_in_channel, got: %v", err)
+	}
+}
+
+// Requests with the wrong token are rejected.
+func TestInvalidAuth(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	srv.Token = "xoxb-other"
+
+	var resp client.UsersListResponse
+	if _, err := newClient(srv).Execute(client.UsersListRequest{}, &resp); !client.IsSlackError(err, "invalid_auth") {
+		t.Errorf("Expected invalid_auth, got: %v", err)
+	}
+}
+
+// Calls can be updated, joined and ended.
+func TestCallLifecycle(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	c := newClient(srv)
+
+	var callResp client.CallResponse
+	if _, err := c.Execute(client.Call{ExternalUniqueId: "weekly", JoinUrl: "http://zoom"}, &callResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	id := callResp.Call.Id
+
+	if _, err := c.Execute(client.CallUpdateRequest{Id: id, Title: "Game Time!"}, &callResp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	var resp client.GenericResponse
+	users := []client.CallUser{{SlackId: "U1"}, {ExternalId: "e1", DisplayName: "Guest"}}
+	if _, err := c.Execute(client.CallParticipantsAdd{Id: id, Users: users}, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(client.CallParticipantsRemove{Id: id, Users: users[:1]}, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(client.CallEnd{Id: id}, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+
+	if _, err := c.Execute(client.CallInfoRequest{Id: id}, &callResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	call := callResp.Call
+	if call.Title != "Game Time!" || call.JoinUrl != "http://zoom" || call.DateEnd == 0 ||
+		!reflect.DeepEqual(call.Users, users[1:]) {
+		t.Errorf("Unexpected call: %+v", call)
+	}
+
+	if _, err := c.Execute(client.CallInfoRequest{Id: "RMISSING"}, &callResp); !client.IsSlackError(err, "not_found") {
+		t.Errorf("Expected not_found, got: %v", err)
+	}
+}
+
+// Scheduled messages can be listed and deleted until they're due, and are
+// then posted.
+func TestScheduledMessages(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	start := time.Date(2026, 10, 6, 8, 0, 0, 0, time.UTC)
+	srv.Now = func() time.Time { return start }
+	id := srv.AddChannel("weekly", "UBOT")
+	c := newClient(srv)
+
+	var scheduleResp client.ScheduleMessageResponse
+	schedule := client.ScheduleMessageRequest{ChannelId: id, Text: "past", PostAt: start.Unix()}
+	if _, err := c.Execute(schedule, &scheduleResp); !client.IsSlackError(err, "time_in_past") {
+		t.Errorf("Expected time_in_past, got: %v", err)
+	}
+	schedule.Text, schedule.PostAt = "reminder", start.Add(time.Hour).Unix()
+	if _, err := c.Execute(schedule, &scheduleResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	reminder := scheduleResp.ScheduledMessageId
+	schedule.Text, schedule.PostAt = "announcement", start.Add(2*time.Hour).Unix()
+	if _, err := c.Execute(schedule, &scheduleResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	var listResp client.ScheduledMessagesListResponse
+	if _, err := c.Execute(client.ScheduledMessagesListRequest{ChannelId: id}, &listResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(listResp.ScheduledMessages) != 2 || listResp.ScheduledMessages[0].Id != reminder ||
+		!listResp.ScheduledMessages[1].PostTime().Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Unexpected scheduled messages: %+v", listResp.ScheduledMessages)
+	}
+
+	var resp client.GenericResponse
+	remove := client.DeleteScheduledMessageRequest{ChannelId: id, ScheduledMessageId: reminder}
+	if _, err := c.Execute(remove, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(remove, &resp); !client.IsSlackError(err, "invalid_scheduled_message_id") {
+		t.Errorf("Expected invalid_scheduled_message_id, got: %v", err)
+	}
+
+	srv.Now = func() time.Time { return start.Add(3 * time.Hour) }
+	if _, err := c.Execute(client.ScheduledMessagesListRequest{}, &listResp); err != nil || len(listResp.ScheduledMessages) != 0 {
+		t.Errorf("Expected no scheduled messages, got %+v, %v", listResp.ScheduledMessages, err)
+	}
+	if messages := srv.Messages(id); len(messages) != 1 || messages[0].Text != "announcement" {
+		t.Errorf("Expected the announcement to be posted, got %+v", messages)
+	}
+}
+
+// Bookmarks can be added, edited, listed and removed.
+func TestBookmarks(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	id := srv.AddChannel("weekly", "UBOT")
+	c := newClient(srv)
+
+	var bookmarkResp client.BookmarkResponse
+	add := client.BookmarksAddRequest{ChannelId: id, Title: "Rules", Type: client.BookmarkLink, Link: "http://rules"}
+	if _, err := c.Execute(add, &bookmarkResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	bookmarkId := bookmarkResp.Bookmark.Id
+	add.Type = "folder"
+	if _, err := c.Execute(add, &bookmarkResp); !client.IsSlackError(err, "invalid_bookmark_type") {
+		t.Errorf("Expected invalid_bookmark_type, got: %v", err)
+	}
+
+	edit := client.BookmarksEditRequest{ChannelId: id, BookmarkId: bookmarkId, Link: "http://rules/v2"}
+	if _, err := c.Execute(edit, &bookmarkResp); err != nil ||
+		bookmarkResp.Bookmark.Title != "Rules" || bookmarkResp.Bookmark.Link != "http://rules/v2" {
+		t.Errorf("Expected the link to be edited, got %+v, %v", bookmarkResp.Bookmark, err)
+	}
+
+	var listResp client.BookmarksListResponse
+	if _, err := c.Execute(client.BookmarksListRequest{ChannelId: id}, &listResp); err != nil ||
+		len(listResp.Bookmarks) != 1 || listResp.Bookmarks[0].Id != bookmarkId {
+		t.Errorf("Expected one bookmark, got %+v, %v", listResp.Bookmarks, err)
+	}
+
+	var resp client.GenericResponse
+	remove := client.BookmarksRemoveRequest{ChannelId: id, BookmarkId: bookmarkId}
+	if _, err := c.Execute(remove, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(remove, &resp); !client.IsSlackError(err, "not_found") {
+		t.Errorf("Expected not_found, got: %v", err)
+	}
+	if bookmarks := srv.Bookmarks(id); len(bookmarks) != 0 {
+		t.Errorf("Expected no bookmarks, got %+v", bookmarks)
+	}
+}
+
+// Posted messages can be edited, pinned, reacted to and deleted.
+func TestMessageLifecycle(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	id := srv.AddChannel("weekly", "UBOT", "U1")
+	c := newClient(srv)
+
+	var postResp client.PostMessageResponse
+	if _, err := c.Execute(client.PostMessageRequest{ChannelId: id, Text: "hello"}, &postResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if postResp.Channel != id || len(postResp.Ts) == 0 || postResp.Message.Text != "hello" {
+		t.Errorf("Unexpected response: %+v", postResp)
+	}
+	ts := postResp.Ts
+
+	// Like Slack, the text is escaped and links are wrapped.
+	update := client.UpdateMessageRequest{ChannelId: id, Ts: ts, Text: "hello & https://example.com/?a=1&b=2"}
+	if _, err := c.Execute(update, &postResp); err != nil ||
+		postResp.Message.Text != "hello &amp; <https://example.com/?a=1&amp;b=2>" {
+		t.Errorf("Expected the message to be updated, got %+v, %v", postResp, err)
+	}
+	// Links that are already formatted are kept.
+	update.Text = "hi <@U1> " + client.FormatLink("https://example.com/?a=1&b=2")
+	if _, err := c.Execute(update, &postResp); err != nil ||
+		postResp.Message.Text != "hi <@U1> <https://example.com/?a=1&amp;b=2>" {
+		t.Errorf("Expected the links to be kept, got %+v, %v", postResp, err)
+	}
+	other := srv.AddMessage(slacktest.Message{ChannelId: id, User: "U1", Text: "hi"})
+	if _, err := c.Execute(client.UpdateMessageRequest{ChannelId: id, Ts: other.Ts, Text: "bye"}, &postResp); !client.IsSlackError(err, "cant_update_message") {
+		t.Errorf("Expected cant_update_message, got: %v", err)
+	}
+
+	var resp client.GenericResponse
+	pin := client.PinsAddRequest{ChannelId: id, Timestamp: ts}
+	if _, err := c.Execute(pin, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(pin, &resp); !client.IsSlackError(err, "already_pinned") {
+		t.Errorf("Expected already_pinned, got: %v", err)
+	}
+
+	react := client.ReactionsAddRequest{ChannelId: id, Timestamp: ts, Name: "tada"}
+	if _, err := c.Execute(react, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(react, &resp); !client.IsSlackError(err, "already_reacted") {
+		t.Errorf("Expected already_reacted, got: %v", err)
+	}
+
+	var reactionsResp client.ReactionsGetResponse
+	if _, err := c.Execute(client.ReactionsGetRequest{ChannelId: id, Timestamp: ts}, &reactionsResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	msg := reactionsResp.Message
+	if !msg.IsPinned(id) || len(msg.Reactions) != 1 || msg.Reactions[0].Name != "tada" || msg.Reactions[0].Count != 1 {
+		t.Errorf("Expected a pinned message with a reaction, got %+v", msg)
+	}
+
+	unpin := client.PinsRemoveRequest{ChannelId: id, Timestamp: ts}
+	if _, err := c.Execute(unpin, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(unpin, &resp); !client.IsSlackError(err, "no_pin") {
+		t.Errorf("Expected no_pin, got: %v", err)
+	}
+
+	remove := client.DeleteMessageRequest{ChannelId: id, Ts: ts}
+	if _, err := c.Execute(remove, &resp); err != nil {
+		t.Errorf("Got error: %v", err)
+	}
+	if _, err := c.Execute(remove, &resp); !client.IsSlackError(err, "message_not_found") {
+		t.Errorf("Expected message_not_found, got: %v", err)
+	}
+	if messages := srv.Messages(id); len(messages) != 1 || messages[0].Ts != other.Ts {
+		t.Errorf("Expected only the user's message, got %+v", messages)
+	}
+}
+
+// History is newest first without replies, and can be filtered by time.
+func TestConversationsHistory(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+	id := srv.AddChannel("weekly", "U1")
+	c := newClient(srv)
+
+	first := srv.AddMessage(slacktest.Message{ChannelId: id, User: "U1", Text: "first", Ts: "1000.000001"})
+	srv.AddMessage(slacktest.Message{ChannelId: id, User: "U2", Text: "reply", Ts: "1001.000001", ThreadTs: first.Ts})
+	srv.AddMessage(slacktest.Message{ChannelId: id, User: "U1", Text: "second", Ts: "1002.000001"})
+	srv.AddMessage(slacktest.Message{ChannelId: id, User: "U2", Text: "third", Ts: "1003.000001"})
+
+	var texts []string
+	var resp client.MessagesResponse
+	err := client.Paginate(context.Background(), c,
+		client.ConversationsHistoryRequest{ChannelId: id}, &resp, func() error {
+			for _, msg := range resp.Messages {
+				texts = append(texts, msg.Text)
+				if msg.Text == "first" && (msg.ReplyCount != 1 || !msg.IsThreadParent()) {
+					t.Errorf("Expected a thread parent with 1 reply, got %+v", msg)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if expected := []string{"third", "second", "first"}; !reflect.DeepEqual(expected, texts) {
+		t.Errorf("Expected %v, got %v", expected, texts)
+	}
+
+	filter := client.MessageFilter{Oldest: "1000.000001", Latest: "1003.000001"}
+	if _, err := c.Execute(client.ConversationsHistoryRequest{ChannelId: id, MessageFilter: filter}, &resp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].Text != "second" {
+		t.Errorf("Expected only the second message, got %+v", resp.Messages)
+	}
+
+	if _, err := c.Execute(client.ConversationsRepliesRequest{ChannelId: id, Ts: first.Ts}, &resp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(resp.Messages) != 2 || resp.Messages[0].Text != "first" || !resp.Messages[1].IsReply() {
+		t.Errorf("Expected the thread, got %+v", resp.Messages)
+	}
+	if _, err := c.Execute(client.ConversationsRepliesRequest{ChannelId: id, Ts: "1.0"}, &resp); !client.IsSlackError(err, "thread_not_found") {
+		t.Errorf("Expected thread_not_found, got: %v", err)
+	}
+}
+
+// Forced invites skip the users who can't be invited, and report them.
+func TestForcedInvite(t *testing.T) {
+	srv := slacktest.NewServer()
+	defer srv.Close()
+	srv.AddUser(client.User{Id: "U1", Name: "alice"})
+	srv.AddUser(client.User{Id: "U2", Name: "bob"})
+	id := srv.AddChannel("weekly", "UBOT", "U1")
+	c := newClient(srv)
+
+	var resp client.InviteResponse
+	_, err := c.Execute(client.ConversationInvite{ChannelId: id, Users: []string{"U1", "U2", "UNKNOWN"}}, &resp)
+	if !client.IsAlreadyInChannel(err) || len(resp.Errors) != 2 {
+		t.Errorf("Expected already_in_channel with 2 errors, got: %v, %+v", err, resp.Errors)
+	}
+
+	_, err = c.Execute(client.ConversationInvite{ChannelId: id, Users: []string{"U1", "U2", "UNKNOWN"}, Force: true}, &resp)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	expected := []client.InviteError{{User: "U1", Error: "already_in_channel"}, {User: "UNKNOWN", Error: "user_not_found"}}
+	if !reflect.DeepEqual(expected, resp.Errors) {
+		t.Errorf("Expected errors %+v, got %+v", expected, resp.Errors)
+	}
+
+	var membersResp client.ConversationMembersResponse
+	if _, err := c.Execute(client.ConversationMembersRequest{ChannelId: id}, &membersResp); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if expected := []string{"UBOT", "U1", "U2"}; !reflect.DeepEqual(expected, membersResp.Members) {
+		t.Errorf("Expected members %v, got %v", expected, membersResp.Members)
+	}
+}
@@ -3,33 +3,93 @@
 package client
 
 import (
-	"log"
 	"net/url"
+	"strings"
+	"time"
 )
 
 // conversations.create request. Uses ChannelResponse.
 type CreateChannelRequest struct {
-	Name string `json:"name"`
+	Name      string `json:"name"`
+	IsPrivate bool   `json:"is_private,omitempty"`
 }
 
-// conversations.invite request. Uses ChannelResponse.
+// MaxInviteUsers is the most users conversations.invite accepts at once.
+const MaxInviteUsers = 1000
+
+// conversations.invite request. Uses InviteResponse, or ChannelResponse.
+// At most MaxInviteUsers can be invited per request.
 type ConversationInvite struct {
 	ChannelId string   `json:"channel"`
 	Users     []string `json:"users"`
+	// Force invites the valid users even if others can't be invited,
+	// reporting those in InviteResponse.Errors.
+	Force bool `json:"force,omitempty"`
+}
+
+// InviteResponse is the response to conversations.invite.
+type InviteResponse struct {
+	SlackResponse
+	Channel Channel `json:"channel"`
+	// Errors lists the users who couldn't be invited, and why.
+	Errors []InviteError `json:"errors"`
+}
+
+// InviteError is why a user couldn't be invited, e.g. "already_in_channel"
+// or "user_is_restricted".
+type InviteError struct {
+	User  string `json:"user"`
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// conversations.members request. Uses ConversationMembersResponse.
+type ConversationMembersRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	ChannelId string
+	Cursor    string
+	Limit     string
+}
+
+type ConversationMembersResponse struct {
+	SlackResponse
+	// Members are user IDs.
+	Members []string `json:"members"`
 }
 
 type Channel struct {
 	Id   string `json:"id"`
 	Name string `json:"name"`
+	// Created is a Unix timestamp, see CreatedTime.
+	Created int64 `json:"created"`
+	// Creator is the ID of the user who created the channel.
+	Creator    string `json:"creator"`
+	IsArchived bool   `json:"is_archived"`
+	IsPrivate  bool   `json:"is_private"`
+	// NumMembers is only set by conversations.info with IncludeNumMembers,
+	// and by conversations.list.
+	NumMembers    int         `json:"num_members"`
+	Topic         ChannelText `json:"topic"`
+	Purpose       ChannelText `json:"purpose"`
+	PreviousNames []string    `json:"previous_names"`
+}
+
+// CreatedTime returns when the channel was created.
+func (c Channel) CreatedTime() time.Time {
+	return time.Unix(c.Created, 0)
+}
+
+// ChannelText is a channel's topic or purpose.
+type ChannelText struct {
+	Value   string `json:"value"`
+	Creator string `json:"creator"`
+	// LastSet is a Unix timestamp.
+	LastSet int64 `json:"last_set"`
 }
 
 type ChannelResponse struct {
-	Ok      bool    `json:"ok"`
+	SlackResponse
 	Channel Channel `json:"channel"`
-
-	Error       string `json:"error"`
-	ErrorDetail string `json:"detail"`
-	Warning     string `json:"warning"`
 }
 
 // conversations.archive request. Uses GenericResponse.
@@ -37,12 +97,48 @@ type ChannelArchiveRequest struct {
 	ChannelId string `json:"channel"`
 }
 
+// conversations.unarchive request. Uses GenericResponse.
+type ChannelUnarchiveRequest struct {
+	ChannelId string `json:"channel"`
+}
+
+// SlackResponse holds the fields common to all responses.
+// Execute returns a *SlackError when Ok is false, so callers rarely need to
+// check these.
+type SlackResponse struct {
+	Ok          bool             `json:"ok"`
+	Error       string           `json:"error"`
+	ErrorDetail string           `json:"detail"`
+	Warning     string           `json:"warning"`
+	Metadata    ResponseMetadata `json:"response_metadata"`
+}
+
 // GenericResponse represents a generic response for
 // methods that don't return more specific information.
 type GenericResponse struct {
-	Ok      bool   `json:"ok"`
-	Warning string `json:"warning"`
-	Error   string `json:"error"`
+	SlackResponse
+}
+
+// conversations.info request. Uses ChannelResponse.
+type ChannelInfoRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	ChannelId         string
+	IncludeNumMembers bool
+}
+
+// auth.test request, to find out who the token belongs to.
+// Uses AuthTestResponse.
+type AuthTestRequest struct{}
+
+type AuthTestResponse struct {
+	SlackResponse
+	Url    string `json:"url"`
+	Team   string `json:"team"`
+	User   string `json:"user"`
+	TeamId string `json:"team_id"`
+	UserId string `json:"user_id"`
+	// BotId is set for bot tokens.
+	BotId string `json:"bot_id"`
 }
 
 // conversations.setTopic request. Uses GenericResponse.
@@ -51,35 +147,64 @@ type ChannelSetTopicRequest struct {
 	Topic     string `json:"topic"`
 }
 
-type Block struct {
-	Type   string `json:"type"`
-	CallId string `json:"call_id"`
+// conversations.setPurpose request. Uses GenericResponse.
+type ChannelSetPurposeRequest struct {
+	ChannelId string `json:"channel"`
+	Purpose   string `json:"purpose"`
 }
 
-// chat.postMessage request. Uses GenericResponse.
+// chat.postMessage request. Uses PostMessageResponse.
 type PostMessageRequest struct {
-	ChannelId string  `json:"channel"`
-	Text      string  `json:"text"`
-	Blocks    []Block `json:"blocks"`
-}
+	ChannelId string `json:"channel"`
+	// Text is the fallback for notifications when there are Blocks.
+	Text   string `json:"text"`
+	Blocks Blocks `json:"blocks,omitempty"`
+	// ThreadTs, if set, posts a reply in the thread of that message.
+	ThreadTs string `json:"thread_ts,omitempty"`
+}
+
+// PostMessageResponse is the response to chat.postMessage and chat.update.
+type PostMessageResponse struct {
+	SlackResponse
+	Channel string `json:"channel"`
+	// Ts identifies the message, for editing, pinning or reacting to it.
+	Ts      string  `json:"ts"`
+	Message Message `json:"message"`
+}
+
+// Channel types, for ChannelListRequest.
+const (
+	PublicChannel  = "public_channel"
+	PrivateChannel = "private_channel"
+	MultiPartyIM   = "mpim"
+	DirectMessage  = "im"
+)
 
 // conversations.List request. Uses ChannelListResponse.
+// By default, lists unarchived public channels.
 type ChannelListRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
 	Cursor string
+	Limit  string
+	// Types of channel to list, e.g. PrivateChannel. Defaults to
+	// PublicChannel.
+	Types []string
+	// IncludeArchived lists archived channels too.
+	IncludeArchived bool
 }
 
 type ChannelListResponse struct {
-	Ok       bool             `json:"ok"`
-	Channels []Channel        `json:"channels"`
-	Metadata ResponseMetadata `json:"response_metadata"`
-	Warning  string           `json:"warning"`
-	Error    string           `json:"error"`
+	SlackResponse
+	Channels []Channel `json:"channels"`
 }
 
 // Call is used for calls.add requests, and also part of the CallResponse.
 type Call struct {
 	// Return-only
 	Id string `json:"id"`
+	// DateEnd is set once the call has ended.
+	DateEnd  int64    `json:"date_end,omitempty"`
+	Channels []string `json:"channels,omitempty"`
 
 	// Required for requests
 	ExternalUniqueId string `json:"external_unique_id"`
@@ -90,137 +215,310 @@ type Call struct {
 	DesktopAppJoinUrl string `json:"desktop_app_join_url"`
 	ExternalDisplayId string `json:"external_display_id"`
 	Title             string `json:"title"`
+	// Users are the participants.
+	Users []CallUser `json:"users,omitempty"`
 }
 
+// CallUser is a participant of a Call, either a Slack user or an external
+// one.
+type CallUser struct {
+	SlackId string `json:"slack_id,omitempty"`
+
+	ExternalId  string `json:"external_id,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarUrl   string `json:"avatar_url,omitempty"`
+}
+
+// CallResponse is the response to calls.add, calls.info and calls.update.
 type CallResponse struct {
-	Ok      bool   `json:"ok"`
-	Call    Call   `json:"call"`
-	Warning string `json:"warning"`
-	Error   string `json:"error"`
+	SlackResponse
+	Call Call `json:"call"`
 }
 
 // calls.end Request. Uses GenericResponse.
 type CallEnd struct {
 	Id string `json:"id"`
+	// Duration of the call in seconds, optional.
+	Duration int64 `json:"duration,omitempty"`
 }
 
-// users.list request. Uses UsersListResponse.
-type UsersListRequest struct {
-	// These don't encode to JSON, since this isn't a POST request.
-	Cursor string
-	Limit  string
+// calls.info request. Uses CallResponse.
+type CallInfoRequest struct {
+	// This doesn't encode to JSON, since this isn't a POST request.
+	Id string
+}
+
+// calls.update request. Uses CallResponse.
+// Only the fields set are changed.
+type CallUpdateRequest struct {
+	Id                string `json:"id"`
+	Title             string `json:"title,omitempty"`
+	JoinUrl           string `json:"join_url,omitempty"`
+	DesktopAppJoinUrl string `json:"desktop_app_join_url,omitempty"`
 }
 
-type User struct {
-	Id      string `json:"id"`
-	Name    string `json:"name"`
-	Deleted bool   `json:"deleted"`
-	IsBot   bool   `json:"is_bot"`
+// calls.participants.add request. Uses GenericResponse.
+type CallParticipantsAdd struct {
+	Id    string     `json:"id"`
+	Users []CallUser `json:"users"`
+}
+
+// calls.participants.remove request. Uses GenericResponse.
+type CallParticipantsRemove struct {
+	Id    string     `json:"id"`
+	Users []CallUser `json:"users"`
 }
 
 type ResponseMetadata struct {
 	// NextCursor is used by paginating methods.
 	NextCursor string `json:"next_cursor"`
+	// Messages explain errors or warnings, e.g. invalid arguments.
+	Messages []string `json:"messages"`
+	// Warnings lists warning codes, e.g. "missing_charset".
+	Warnings []string `json:"warnings"`
+}
+
+func (r PostMessageRequest) Method() string {
+	return "chat.postMessage"
 }
 
-type UsersListResponse struct {
-	Ok       bool             `json:"ok"`
-	Members  []User           `json:"members"`
-	Metadata ResponseMetadata `json:"response_metadata"`
+func (r PostMessageRequest) Verb() string {
+	return "POST"
+}
 
-	Error       string `json:"error"`
-	ErrorDetail string `json:"detail"`
+func (r PostMessageRequest) Tier() RateTier {
+	return TierSpecial
 }
 
-func (r PostMessageRequest) URL() string {
-	return "https://slack.com/api/chat.postMessage"
+func (r ChannelSetTopicRequest) Method() string {
+	return "conversations.setTopic"
 }
 
-func (r PostMessageRequest) Verb() string {
+func (r ChannelSetTopicRequest) Verb() string {
 	return "POST"
 }
 
-func (r ChannelSetTopicRequest) URL() string {
-	return "https://slack.com/api/conversations.setTopic"
+func (r ChannelSetTopicRequest) Tier() RateTier {
+	return Tier2
 }
 
-func (r ChannelSetTopicRequest) Verb() string {
+func (r ChannelInfoRequest) Method() string {
+	return "conversations.info"
+}
+
+func (r ChannelInfoRequest) Verb() string {
+	return "GET"
+}
+
+func (r ChannelInfoRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r ChannelInfoRequest) Query() url.Values {
+	query := url.Values{}
+	query.Set("channel", r.ChannelId)
+	if r.IncludeNumMembers {
+		query.Set("include_num_members", "true")
+	}
+	return query
+}
+
+func (r AuthTestRequest) Method() string {
+	return "auth.test"
+}
+
+func (r AuthTestRequest) Verb() string {
+	return "GET"
+}
+
+func (r AuthTestRequest) Tier() RateTier {
+	return TierSpecial
+}
+
+func (r AuthTestRequest) Query() url.Values {
+	return url.Values{}
+}
+
+func (r ChannelSetPurposeRequest) Method() string {
+	return "conversations.setPurpose"
+}
+
+func (r ChannelSetPurposeRequest) Verb() string {
 	return "POST"
 }
 
-func (r ChannelArchiveRequest) URL() string {
-	return "https://slack.com/api/conversations.archive"
+func (r ChannelSetPurposeRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r ChannelArchiveRequest) Method() string {
+	return "conversations.archive"
 }
 
 func (r ChannelArchiveRequest) Verb() string {
 	return "POST"
 }
 
-func (r CreateChannelRequest) URL() string {
-	return "https://slack.com/api/conversations.create"
+func (r ChannelArchiveRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r ChannelUnarchiveRequest) Method() string {
+	return "conversations.unarchive"
+}
+
+func (r ChannelUnarchiveRequest) Verb() string {
+	return "POST"
+}
+
+func (r ChannelUnarchiveRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r CreateChannelRequest) Method() string {
+	return "conversations.create"
 }
 func (r CreateChannelRequest) Verb() string {
 	return "POST"
 }
+func (r CreateChannelRequest) Tier() RateTier {
+	return Tier2
+}
 
-func (r ConversationInvite) URL() string {
-	return "https://slack.com/api/conversations.invite"
+func (r ConversationInvite) Method() string {
+	return "conversations.invite"
 }
 func (r ConversationInvite) Verb() string {
 	return "POST"
 }
+func (r ConversationInvite) Tier() RateTier {
+	return Tier3
+}
+
+func (r ConversationMembersRequest) Verb() string {
+	return "GET"
+}
+
+func (r ConversationMembersRequest) Tier() RateTier {
+	return Tier4
+}
+
+func (r ConversationMembersRequest) Method() string {
+	return "conversations.members"
+}
+
+func (r ConversationMembersRequest) Query() url.Values {
+	query := url.Values{}
+	query.Set("channel", r.ChannelId)
+	if len(r.Cursor) > 0 {
+		query.Set("cursor", r.Cursor)
+	}
+	if len(r.Limit) > 0 {
+		query.Set("limit", r.Limit)
+	}
+	return query
+}
+
+func (r ConversationMembersRequest) WithCursor(cursor string) PaginatedRequest {
+	r.Cursor = cursor
+	return r
+}
 
 func (r CallEnd) Verb() string {
 	return "POST"
 }
-func (r CallEnd) URL() string {
-	return "https://slack.com/api/calls.end"
+func (r CallEnd) Tier() RateTier {
+	return Tier2
+}
+func (r CallEnd) Method() string {
+	return "calls.end"
 }
 
 func (r Call) Verb() string {
 	return "POST"
 }
-func (r Call) URL() string {
-	return "https://slack.com/api/calls.add"
+func (r Call) Tier() RateTier {
+	return Tier2
+}
+func (r Call) Method() string {
+	return "calls.add"
 }
 
-func (r ChannelListRequest) Verb() string {
+func (r CallInfoRequest) Verb() string {
 	return "GET"
 }
+func (r CallInfoRequest) Tier() RateTier {
+	return Tier2
+}
+func (r CallInfoRequest) Method() string {
+	return "calls.info"
+}
+func (r CallInfoRequest) Query() url.Values {
+	return url.Values{"id": {r.Id}}
+}
 
-func (r ChannelListRequest) URL() string {
-	u, err := url.Parse("https://slack.com/api/conversations.list")
-	if err != nil {
-		log.Fatal(err)
-	}
-	query := u.Query()
-	query.Set("exclude_archived", "true")
-	query.Set("types", "public_channel")
-	if len(r.Cursor) > 0 {
-		query.Set("cursor", r.Cursor)
-	}
-	u.RawQuery = query.Encode()
+func (r CallUpdateRequest) Verb() string {
+	return "POST"
+}
+func (r CallUpdateRequest) Tier() RateTier {
+	return Tier2
+}
+func (r CallUpdateRequest) Method() string {
+	return "calls.update"
+}
+
+func (r CallParticipantsAdd) Verb() string {
+	return "POST"
+}
+func (r CallParticipantsAdd) Tier() RateTier {
+	return Tier2
+}
+func (r CallParticipantsAdd) Method() string {
+	return "calls.participants.add"
+}
 
-	return u.String()
+func (r CallParticipantsRemove) Verb() string {
+	return "POST"
+}
+func (r CallParticipantsRemove) Tier() RateTier {
+	return Tier2
+}
+func (r CallParticipantsRemove) Method() string {
+	return "calls.participants.remove"
 }
 
-func (r UsersListRequest) Verb() string {
+func (r ChannelListRequest) Verb() string {
 	return "GET"
 }
 
-func (r UsersListRequest) URL() string {
-	u, err := url.Parse("https://slack.com/api/users.list")
-	if err != nil {
-		log.Fatal(err)
+func (r ChannelListRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r ChannelListRequest) Method() string {
+	return "conversations.list"
+}
+
+func (r ChannelListRequest) Query() url.Values {
+	query := url.Values{}
+	if !r.IncludeArchived {
+		query.Set("exclude_archived", "true")
+	}
+	if len(r.Types) > 0 {
+		query.Set("types", strings.Join(r.Types, ","))
+	} else {
+		query.Set("types", PublicChannel)
 	}
-	query := u.Query()
 	if len(r.Cursor) > 0 {
 		query.Set("cursor", r.Cursor)
 	}
 	if len(r.Limit) > 0 {
 		query.Set("limit", r.Limit)
 	}
-	u.RawQuery = query.Encode()
+	return query
+}
 
-	return u.String()
+func (r ChannelListRequest) WithCursor(cursor string) PaginatedRequest {
+	r.Cursor = cursor
+	return r
 }
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"net/url"
+	"time"
+)
+
+// SlackbotId is Slackbot's user ID. Slackbot is listed by users.list, but
+// isn't flagged as a bot.
+const SlackbotId = "USLACKBOT"
+
+type User struct {
+	Id       string `json:"id"`
+	TeamId   string `json:"team_id,omitempty"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name,omitempty"`
+	Deleted  bool   `json:"deleted"`
+	IsBot    bool   `json:"is_bot"`
+	// IsAppUser is set for users installed by an app, rather than invited.
+	IsAppUser bool `json:"is_app_user,omitempty"`
+	// IsRestricted is set for guests, and IsUltraRestricted too for
+	// single-channel guests.
+	IsRestricted      bool `json:"is_restricted,omitempty"`
+	IsUltraRestricted bool `json:"is_ultra_restricted,omitempty"`
+
+	// Tz is the user's IANA time zone, e.g. "America/Los_Angeles".
+	Tz      string `json:"tz,omitempty"`
+	TzLabel string `json:"tz_label,omitempty"`
+	// TzOffset is the user's current offset from UTC, in seconds.
+	TzOffset int `json:"tz_offset,omitempty"`
+
+	Profile UserProfile `json:"profile"`
+}
+
+type UserProfile struct {
+	RealName    string `json:"real_name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	// Email needs the users:read.email scope.
+	Email string `json:"email,omitempty"`
+}
+
+// IsGuest reports whether the user is a single- or multi-channel guest.
+func (u User) IsGuest() bool {
+	return u.IsRestricted || u.IsUltraRestricted
+}
+
+// IsPerson reports whether the user is a full member of the workspace, i.e.
+// not a bot, app user, Slackbot or guest, and not deleted.
+func (u User) IsPerson() bool {
+	return !u.Deleted && !u.IsBot && !u.IsAppUser && !u.IsGuest() && u.Id != SlackbotId
+}
+
+// DisplayName returns the name people see in Slack: the display name, the
+// real name, or the username, whichever is set first.
+func (u User) DisplayName() string {
+	switch {
+	case len(u.Profile.DisplayName) > 0:
+		return u.Profile.DisplayName
+	case len(u.Profile.RealName) > 0:
+		return u.Profile.RealName
+	case len(u.RealName) > 0:
+		return u.RealName
+	}
+	return u.Name
+}
+
+// Location returns the user's time zone. Falls back to a fixed zone of
+// TzOffset if Tz is unknown.
+func (u User) Location() *time.Location {
+	if len(u.Tz) > 0 {
+		if loc, err := time.LoadLocation(u.Tz); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(u.TzLabel, u.TzOffset)
+}
+
+// users.list request. Uses UsersListResponse.
+type UsersListRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	Cursor string
+	Limit  string
+}
+
+type UsersListResponse struct {
+	SlackResponse
+	Members []User `json:"members"`
+}
+
+// users.info request. Uses UserResponse.
+type UsersInfoRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	UserId string
+}
+
+// users.lookupByEmail request. Uses UserResponse.
+// Fails with "users_not_found" if nobody has the email.
+type UsersLookupByEmailRequest struct {
+	// These don't encode to JSON, since this isn't a POST request.
+	Email string
+}
+
+// UserResponse is the response to users.info and users.lookupByEmail.
+type UserResponse struct {
+	SlackResponse
+	User User `json:"user"`
+}
+
+func (r UsersListRequest) Verb() string {
+	return "GET"
+}
+
+func (r UsersListRequest) Tier() RateTier {
+	return Tier2
+}
+
+func (r UsersListRequest) Method() string {
+	return "users.list"
+}
+
+func (r UsersListRequest) Query() url.Values {
+	query := url.Values{}
+	if len(r.Cursor) > 0 {
+		query.Set("cursor", r.Cursor)
+	}
+	if len(r.Limit) > 0 {
+		query.Set("limit", r.Limit)
+	}
+	return query
+}
+
+func (r UsersListRequest) WithCursor(cursor string) PaginatedRequest {
+	r.Cursor = cursor
+	return r
+}
+
+func (r UsersInfoRequest) Verb() string {
+	return "GET"
+}
+
+func (r UsersInfoRequest) Tier() RateTier {
+	return Tier4
+}
+
+func (r UsersInfoRequest) Method() string {
+	return "users.info"
+}
+
+func (r UsersInfoRequest) Query() url.Values {
+	query := url.Values{}
+	query.Set("user", r.UserId)
+	return query
+}
+
+func (r UsersLookupByEmailRequest) Verb() string {
+	return "GET"
+}
+
+func (r UsersLookupByEmailRequest) Tier() RateTier {
+	return Tier3
+}
+
+func (r UsersLookupByEmailRequest) Method() string {
+	return "users.lookupByEmail"
+}
+
+func (r UsersLookupByEmailRequest) Query() url.Values {
+	query := url.Values{}
+	query.Set("email", r.Email)
+	return query
+}
@@ -0,0 +1,114 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// users.info decodes the full User model.
+func TestUsersInfo(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.info?user=W012A3CDE")).Return(
+		HttpResponseWithBody(`{
+			"ok": true,
+			"user": {
+				"id": "W012A3CDE",
+				"team_id": "T012AB3C4",
+				"name": "spengler",
+				"real_name": "Egon Spengler",
+				"deleted": false,
+				"is_bot": false,
+				"is_app_user": false,
+				"is_restricted": true,
+				"is_ultra_restricted": false,
+				"tz": "America/Los_Angeles",
+				"tz_label": "Pacific Daylight Time",
+				"tz_offset": -25200,
+				"profile": {
+					"real_name": "Egon Spengler",
+					"display_name": "spengler",
+					"email": "spengler@ghostbusters.example.com"
+				}
+			}
+		}`), nil).Times(1)
+
+	var actual client.UserResponse
+	ExpectEqual(t, slackClient,
+		/*req=*/ client.UsersInfoRequest{UserId: "W012A3CDE"},
+		/*actual=*/ &actual,
+		/*expected=*/ &client.UserResponse{
+			SlackResponse: client.SlackResponse{Ok: true},
+			User: client.User{
+				Id:           "W012A3CDE",
+				TeamId:       "T012AB3C4",
+				Name:         "spengler",
+				RealName:     "Egon Spengler",
+				IsRestricted: true,
+				Tz:           "America/Los_Angeles",
+				TzLabel:      "Pacific Daylight Time",
+				TzOffset:     -25200,
+				Profile: client.UserProfile{
+					RealName:    "Egon Spengler",
+					DisplayName: "spengler",
+					Email:       "spengler@ghostbusters.example.com",
+				},
+			}})
+
+	user := actual.User
+	if !user.IsGuest() || user.IsPerson() {
+		t.Errorf("Expected a guest: %+v", user)
+	}
+	if loc := user.Location(); loc.String() != "America/Los_Angeles" {
+		t.Errorf("Unexpected location %v", loc)
+	}
+}
+
+func TestUsersLookupByEmail(t *testing.T) {
+	mockHttp, slackClient := getClient(t, "my-auth-token")
+
+	mockHttp.EXPECT().Do(HasUrl("https://slack.com/api/users.lookupByEmail?email=alice%40example.com")).Return(
+		HttpResponseWithBody(`{"ok": false, "error": "users_not_found"}`), nil).Times(1)
+
+	var resp client.UserResponse
+	_, err := slackClient.Execute(client.UsersLookupByEmailRequest{Email: "alice@example.com"}, &resp)
+	if !client.IsSlackError(err, "users_not_found") {
+		t.Errorf("Expected users_not_found, got: %v", err)
+	}
+}
+
+func TestUserNames(t *testing.T) {
+	user := client.User{Id: "U1", Name: "alice", TzOffset: 3600}
+	if name := user.DisplayName(); name != "alice" {
+		t.Errorf("Expected the username, got %q", name)
+	}
+	user.RealName = "Alice Liddell"
+	if name := user.DisplayName(); name != "Alice Liddell" {
+		t.Errorf("Expected the real name, got %q", name)
+	}
+	user.Profile.DisplayName = "ally"
+	if name := user.DisplayName(); name != "ally" {
+		t.Errorf("Expected the display name, got %q", name)
+	}
+
+	if !user.IsPerson() {
+		t.Errorf("Expected a person: %+v", user)
+	}
+	for _, other := range []client.User{
+		{Id: client.SlackbotId, Name: "slackbot"},
+		{Id: "U2", IsAppUser: true},
+		{Id: "U3", IsUltraRestricted: true},
+		{Id: "U4", IsBot: true},
+		{Id: "U5", Deleted: true},
+	} {
+		if other.IsPerson() {
+			t.Errorf("Expected %+v not to be a person", other)
+		}
+	}
+
+	if _, offset := time.Unix(0, 0).In(user.Location()).Zone(); offset != 3600 {
+		t.Errorf("Expected a fixed offset zone, got %d", offset)
+	}
+}
@@ -0,0 +1,229 @@
+package janitor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes the rotation. It's loaded at startup from the YAML or
+// JSON file named by JANITOR_CONFIG, janitor.yaml by default. Anything not
+// in the file keeps its default, see defaultConfig.
+type Config struct {
+	// Rotation names the rotation, e.g. "gamenight", for channel names.
+	Rotation string `yaml:"rotation"`
+	// Timezone is where the channels rotate, e.g. "America/Los_Angeles".
+	Timezone  string            `yaml:"timezone"`
+	Naming    ChannelNameConfig `yaml:"channel_name"`
+	Call      CallConfig        `yaml:"call"`
+	Welcome   WelcomeConfig     `yaml:"welcome"`
+	Cadence   CadenceConfig     `yaml:"cadence"`
+	Retention RetentionConfig   `yaml:"retention"`
+	// PrivateChannels rotates private channels instead of public ones.
+	PrivateChannels bool `yaml:"private_channels"`
+	// InviteConcurrency is how many invite batches are sent at once.
+	InviteConcurrency int `yaml:"invite_concurrency"`
+	// FallbackSuffix is appended to a channel name that can't be used, e.g.
+	// because it belongs to an archived channel. "" turns the fallback off.
+	FallbackSuffix string `yaml:"fallback_suffix"`
+	// Reminders are how long before the call reminders are posted.
+	Reminders []time.Duration `yaml:"reminders"`
+	// Links are bookmarked in every channel, after the video call.
+	Links []channelLink `yaml:"links"`
+
+	location *time.Location
+}
+
+// CallConfig describes the video call.
+type CallConfig struct {
+	Title string `yaml:"title"`
+	// Start is when the call starts, e.g. "18:30".
+	Start string `yaml:"start"`
+	// Url and Id may reference environment variables, e.g. ${VC_URL}, so
+	// they can be kept in secrets.
+	Url string `yaml:"url"`
+	Id  string `yaml:"id"`
+
+	startHour, startMinute int
+}
+
+// WelcomeConfig is the welcome message posted in every channel.
+type WelcomeConfig struct {
+	Header string `yaml:"header"`
+	// Text starts the message's text. Re-runs find the message by it, so
+	// changing it posts a new welcome message.
+	Text string `yaml:"text"`
+}
+
+// config is the rotation's configuration. configErr is why it couldn't be
+// loaded, which fails every run.
+var config *Config
+var configErr error
+
+func defaultConfig() *Config {
+	return &Config{
+		Timezone: "America/Los_Angeles",
+		Naming: ChannelNameConfig{
+			Format:     "{{.Date}}",
+			DateLayout: "20060102",
+		},
+		Call: CallConfig{
+			Title: "Game Time!",
+			Start: "18:30",
+			Url:   "${VC_URL}",
+			Id:    "${VC_CALL_ID}",
+		},
+		Welcome: WelcomeConfig{
+			Header: "Hello, welcome to today's channel!",
+			Text:   "Hello, welcome to today's channel.",
+		},
+		Cadence: CadenceConfig{
+			Every:    "weekly",
+			Weekdays: []string{"tuesday"},
+		},
+		InviteConcurrency: 1,
+		FallbackSuffix:    "-2",
+		Reminders:         []time.Duration{time.Hour},
+	}
+}
+
+// configPath is the config file's path, from JANITOR_CONFIG.
+func configPath() string {
+	if path := os.Getenv("JANITOR_CONFIG"); len(path) > 0 {
+		return path
+	}
+	return "janitor.yaml"
+}
+
+// loadConfig reads and validates the config file. A missing file is only an
+// error if JANITOR_CONFIG names it.
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && len(os.Getenv("JANITOR_CONFIG")) == 0 {
+		data = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Error reading the config: %w", err)
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parseConfig decodes YAML or JSON over the defaults, and validates it.
+func parseConfig(data []byte) (*Config, error) {
+	cfg := defaultConfig()
+	// A cadence replaces the default one rather than being merged into it,
+	// so e.g. a monthly cadence doesn't keep the default weekdays.
+	var sections struct {
+		Cadence yaml.Node `yaml:"cadence"`
+	}
+	if err := yaml.Unmarshal(data, &sections); err == nil && sections.Cadence.Kind != 0 {
+		cfg.Cadence = CadenceConfig{}
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+	cfg.Call.Url = os.ExpandEnv(cfg.Call.Url)
+	cfg.Call.Id = os.ExpandEnv(cfg.Call.Id)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	var problems []string
+	var err error
+	if c.location, err = time.LoadLocation(c.Timezone); err != nil || len(c.Timezone) == 0 {
+		problems = append(problems, fmt.Sprintf("unknown timezone %q", c.Timezone))
+	}
+	// Checked in UTC if the timezone is invalid, to report every problem.
+	loc := c.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if err := c.Naming.validate(c.Rotation, c.FallbackSuffix, loc); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := c.Cadence.compile(loc); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(c.Call.Title) == 0 {
+		problems = append(problems, "call.title is empty")
+	}
+	if start, err := time.Parse("15:04", c.Call.Start); err != nil {
+		problems = append(problems, fmt.Sprintf("call.start %q isn't a time like 18:30", c.Call.Start))
+	} else {
+		c.Call.startHour, c.Call.startMinute = start.Hour(), start.Minute()
+	}
+	if len(c.Call.Url) == 0 {
+		problems = append(problems, "call.url is empty, e.g. VC_URL isn't set")
+	}
+	if len(c.Welcome.Header) == 0 || len(c.Welcome.Text) == 0 {
+		problems = append(problems, "welcome.header and welcome.text are required")
+	}
+	if c.Retention.Keep < 0 {
+		problems = append(problems, "retention.keep can't be negative")
+	}
+	if c.InviteConcurrency < 1 {
+		problems = append(problems, "invite_concurrency must be at least 1")
+	}
+	for _, reminder := range c.Reminders {
+		if reminder <= 0 {
+			problems = append(problems, fmt.Sprintf("reminder %v isn't before the call", reminder))
+		}
+	}
+	titles := map[string]bool{videoCallTitle: true}
+	for _, link := range c.Links {
+		if len(link.Title) == 0 || len(link.Url) == 0 {
+			problems = append(problems, fmt.Sprintf("link %+v needs a title and a url", link))
+		} else if titles[link.Title] {
+			problems = append(problems, fmt.Sprintf("link title %q is used twice", link.Title))
+		}
+		titles[link.Title] = true
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Location is the timezone the channels rotate in.
+func (c *Config) Location() *time.Location {
+	return c.location
+}
+
+// CallStart is when the call starts on the day of t.
+func (c *Config) CallStart(t time.Time) time.Time {
+	year, month, day := t.In(c.location).Date()
+	return time.Date(year, month, day, c.Call.startHour, c.Call.startMinute, 0, 0, c.location)
+}
+
+// misconfigured is returned by every run when the config couldn't be loaded.
+func misconfigured(err error) error {
+	return &runError{
+		Status: http.StatusInternalServerError,
+		Code:   "misconfigured",
+		Err:    err,
+	}
+}
+
+func init() {
+	config, configErr = loadConfig(configPath())
+	if configErr != nil {
+		// Every run fails with it, so it's also visible to monitoring.
+		log.Printf("%v", configErr)
+	}
+}
@@ -0,0 +1,236 @@
+package janitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Without a file, the defaults match the original hard-coded rotation.
+func TestDefaultConfig(t *testing.T) {
+	cfg, err := parseConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Call.Url != os.Getenv("VC_URL") || cfg.Call.Id != os.Getenv("VC_CALL_ID") ||
+		cfg.Call.Title != "Game Time!" || cfg.Cadence.Every != "weekly" ||
+		!reflect.DeepEqual(cfg.Cadence.Weekdays, []string{"tuesday"}) ||
+		cfg.Location().String() != "America/Los_Angeles" {
+		t.Errorf("Unexpected defaults: %+v", cfg)
+	}
+
+	day := time.Date(2026, 10, 6, 8, 0, 0, 0, cfg.Location())
+	if start := cfg.CallStart(day); !start.Equal(day.Add(10*time.Hour + 30*time.Minute)) {
+		t.Errorf("Expected the call at 18:30, got %v", start)
+	}
+
+	// The example lists the defaults.
+	example, err := loadConfig("janitor.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	example.Links = nil
+	// Compiled funcs are never equal.
+	cfg.Cadence.on, example.Cadence.on = nil, nil
+	if !reflect.DeepEqual(cfg, example) {
+		t.Errorf("The example doesn't match the defaults:\n%+v\n%+v", example, cfg)
+	}
+}
+
+func TestParseYamlConfig(t *testing.T) {
+	cfg, err := parseConfig([]byte(`
+timezone: Europe/London
+call:
+  title: Board Games
+  start: "19:05"
+  url: https://meet.example.com/${VC_CALL_ID}
+welcome:
+  text: Welcome to board games night.
+cadence:
+  every: daily
+fallback_suffix: ""
+reminders: [2h, 15m]
+links:
+  - title: Rules
+    url: https://example.com/rules
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Call.Url != "https://meet.example.com/123456" {
+		t.Errorf("Expected the URL to be expanded, got %q", cfg.Call.Url)
+	}
+	if cfg.Welcome.Header != defaultConfig().Welcome.Header || cfg.Welcome.Text != "Welcome to board games night." {
+		t.Errorf("Unexpected welcome message: %+v", cfg.Welcome)
+	}
+	// The cadence replaces the default, without its weekdays.
+	if cfg.FallbackSuffix != "" || cfg.Cadence.Every != "daily" || len(cfg.Cadence.Weekdays) != 0 ||
+		cfg.InviteConcurrency != 1 {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+	if expected := []time.Duration{2 * time.Hour, 15 * time.Minute}; !reflect.DeepEqual(expected, cfg.Reminders) {
+		t.Errorf("Expected reminders %v, got %v", expected, cfg.Reminders)
+	}
+	if expected := []channelLink{{Title: "Rules", Url: "https://example.com/rules"}}; !reflect.DeepEqual(expected, cfg.Links) {
+		t.Errorf("Expected links %v, got %v", expected, cfg.Links)
+	}
+
+	day := time.Date(2026, 10, 6, 8, 0, 0, 0, time.UTC)
+	if start := cfg.CallStart(day); start.Format("15:04 MST") != "19:05 BST" {
+		t.Errorf("Expected the call at 19:05 in London, got %v", start)
+	}
+}
+
+func TestParseJsonConfig(t *testing.T) {
+	cfg, err := parseConfig([]byte(`{"call": {"title": "Trivia"}, "reminders": [], "private_channels": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Call.Title != "Trivia" || len(cfg.Reminders) != 0 || !cfg.PrivateChannels {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+}
+
+// Every problem is reported at once.
+func TestInvalidConfig(t *testing.T) {
+	_, err := parseConfig([]byte(`
+timezone: Mars/Olympus_Mons
+call:
+  start: 6:30pm
+cadence:
+  every: fortnightly
+retention:
+  keep: -1
+links:
+  - title: Video Call
+    url: https://example.com
+`))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, problem := range []string{"timezone", "call.start", "fortnightly", "retention.keep", `"Video Call" is used twice`} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected %q to be reported, got: %v", problem, err)
+		}
+	}
+
+	if _, err := parseConfig([]byte(`title: Game Time!`)); err == nil {
+		t.Errorf("Expected unknown fields to be rejected")
+	}
+}
+
+// An invalid config fails every run with a 500.
+func TestCreateChannelInvalidConfig(t *testing.T) {
+	getClient(t)
+	configErr = os.ErrNotExist
+	defer func() { configErr = nil }()
+
+	req, err := http.NewRequest("GET", "/create_channel", nil)
+	req.Header.Add("X-Appengine-Cron", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(CreateChannelHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("unexpected status: got (%v) want (%v)", status, http.StatusInternalServerError)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, `"error":"misconfigured"`) {
+		t.Errorf("Expected a misconfigured failure, got body:\n%v", body)
+	}
+}
+
+// A name format failing on a date validation didn't check fails the run
+// with a 500.
+func TestCreateChannelNameError(t *testing.T) {
+	getClient(t)
+	useConfig(t, `channel_name: {format: "{{if eq .Year 2028}}{{.Month}}{{end}}{{.Date}}"}`)
+	now = func() time.Time { return time.Date(2028, 10, 3, 8, 0, 0, 0, config.Location()) }
+	defer func() { now = time.Now }()
+
+	req, err := http.NewRequest("GET", "/create_channel", nil)
+	req.Header.Add("X-Appengine-Cron", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(CreateChannelHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("unexpected status: got (%v) want (%v)", status, http.StatusInternalServerError)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, `"error":"misconfigured"`) {
+		t.Errorf("Expected a misconfigured failure, got body:\n%v", body)
+	}
+}
+
+func TestChannelNames(t *testing.T) {
+	cfg, err := parseConfig([]byte(`
+rotation: gamenight
+channel_name:
+  format: "{{.Rotation}}-{{.ISOYear}}-w{{.Week}}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		day      time.Time
+		expected string
+	}{
+		{time.Date(2026, 10, 13, 8, 0, 0, 0, cfg.Location()), "gamenight-2026-w42"},
+		{time.Date(2026, 1, 5, 8, 0, 0, 0, cfg.Location()), "gamenight-2026-w02"},
+		// Still the last week of 2020.
+		{time.Date(2021, 1, 1, 8, 0, 0, 0, cfg.Location()), "gamenight-2020-w53"},
+		// Named in the rotation's timezone, where it's still Monday.
+		{time.Date(2026, 10, 13, 3, 0, 0, 0, time.UTC), "gamenight-2026-w42"},
+		{time.Date(2026, 10, 12, 3, 0, 0, 0, time.UTC), "gamenight-2026-w41"},
+	} {
+		if name, err := cfg.ChannelName(test.day); err != nil || name != test.expected {
+			t.Errorf("Expected %s for %v, got %s, %v", test.expected, test.day, name, err)
+		}
+	}
+
+	cfg, err = parseConfig([]byte(`
+channel_name:
+  prefix: gn_
+  date_layout: "2006-01-02"
+  suffix: _evening
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, err := cfg.ChannelName(time.Date(2026, 10, 6, 8, 0, 0, 0, cfg.Location())); err != nil ||
+		name != "gn_2026-10-06_evening" {
+		t.Errorf("Unexpected name %s, %v", name, err)
+	}
+}
+
+// Names Slack would refuse are caught when the config is loaded.
+func TestInvalidChannelNames(t *testing.T) {
+	for _, test := range []struct {
+		config  string
+		problem string
+	}{
+		{`channel_name: {date_layout: "Jan-02"}`, "lowercase"},
+		{"rotation: Game Night\nchannel_name: {format: \"{{.Rotation}}-{{.Date}}\"}", "lowercase"},
+		{`channel_name: {prefix: "` + strings.Repeat("a", 80) + `"}`, "longer than 80"},
+		{`channel_name: {format: "{{.Rotation}"}`, "channel_name.format"},
+		{`channel_name: {format: "{{.Month}}"}`, "channel_name.format"},
+		{`fallback_suffix: "!"`, "fallback_suffix"},
+	} {
+		_, err := parseConfig([]byte(test.config))
+		if err == nil || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("Expected %q to fail with %q, got: %v", test.config, test.problem, err)
+		}
+	}
+}
@@ -0,0 +1,508 @@
+package janitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+	"github.com/jaywhyzed/slackJanitor/client/slacktest"
+)
+
+// startFakeSlack starts a fake Slack API with a few users, and points the
+// handlers at it.
+func startFakeSlack(t *testing.T) *slacktest.Server {
+	srv := slacktest.NewServer()
+	srv.AddUser(client.User{Id: "U1", Name: "alice"})
+	srv.AddUser(client.User{Id: "U2", Name: "bob"})
+	srv.AddUser(client.User{Id: "B1", Name: "otherbot", IsBot: true})
+	srv.AddUser(client.User{Id: "U3", Name: "gone", Deleted: true})
+	srv.AddUser(client.User{Id: client.SlackbotId, Name: "slackbot"})
+	srv.AddUser(client.User{Id: "U5", Name: "guest", IsRestricted: true})
+
+	slackClient = client.NewClient("xoxb-test",
+		client.WithBaseURL(srv.URL()),
+		client.WithRateLimits(false))
+
+	t.Cleanup(func() {
+		srv.Close()
+		slackClient = nil
+		now = time.Now
+	})
+	return srv
+}
+
+// setConfig changes the config for the rest of the test.
+func setConfig(t *testing.T, change func(c *Config)) {
+	old := config
+	changed := *config
+	change(&changed)
+	config = &changed
+	t.Cleanup(func() { config = old })
+}
+
+// useConfig parses text over the defaults, and uses it for the rest of the
+// test.
+func useConfig(t *testing.T, text string) {
+	cfg, err := parseConfig([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := config
+	config = cfg
+	t.Cleanup(func() { config = old })
+}
+
+// chatMessages returns the channel's messages, without system messages
+// like channel_join.
+func chatMessages(srv *slacktest.Server, channelId string) []slacktest.Message {
+	var messages []slacktest.Message
+	for _, msg := range srv.Messages(channelId) {
+		if len(msg.Subtype) == 0 {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// setNow sets the clock of both the handlers and the fake Slack API.
+func setNow(srv *slacktest.Server, t time.Time) {
+	now = func() time.Time { return t }
+	srv.Now = now
+}
+
+// serveCron calls handler like AppEngine Cron would.
+func serveCron(t *testing.T, handler http.HandlerFunc, url string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("X-Appengine-Cron", "true")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("%s returned status %d, body:\n%s", url, rr.Code, rr.Body.String())
+	}
+	return rr
+}
+
+// Runs the weekly rotation for two weeks, including a re-run.
+func TestRotationAcrossWeeks(t *testing.T) {
+	srv := startFakeSlack(t)
+
+	// Week 1: create the channel in the morning, which schedules the call for
+	// the evening.
+	week1 := time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location())
+	setNow(srv, week1)
+	serveCron(t, CreateChannelHandler, "/create_channel")
+
+	channel, found := srv.Channel("20261006")
+	if !found {
+		t.Fatalf("Channel #20261006 wasn't created. Channels: %+v", srv.Channels())
+	}
+	if channel.Topic != "Video Call: http://zoom" {
+		t.Errorf("Unexpected topic %q", channel.Topic)
+	}
+	if expected := []string{"UBOT", "U1", "U2"}; !reflect.DeepEqual(expected, channel.Members) {
+		t.Errorf("Expected members %v, got %v", expected, channel.Members)
+	}
+	if messages := chatMessages(srv, channel.Id); len(messages) != 1 || len(messages[0].Blocks) != 3 {
+		t.Errorf("Expected a welcome message, got %+v", messages)
+	}
+	// Setting the topic and inviting everyone posted system messages, which
+	// the summary skips.
+	if messages := srv.Messages(channel.Id); len(messages) != 4 || messages[0].Subtype != "channel_topic" ||
+		messages[1].Subtype != "channel_join" {
+		t.Errorf("Expected the topic and joins to be posted, got %+v", messages)
+	}
+	calls := srv.Calls()
+	if len(calls) != 1 || calls[0].ExternalUniqueId != "20261006" ||
+		calls[0].StartTimeUnix != week1.Add(10*time.Hour+30*time.Minute).Unix() {
+		t.Fatalf("Unexpected calls: %+v", calls)
+	}
+	scheduled := srv.ScheduledMessages(channel.Id)
+	if len(scheduled) != 2 || !scheduled[0].PostAt.Equal(week1.Add(9*time.Hour+30*time.Minute)) ||
+		!scheduled[1].PostAt.Equal(week1.Add(10*time.Hour+30*time.Minute)) ||
+		len(scheduled[1].Blocks) != 1 || scheduled[1].Blocks[0] != (client.CallBlock{CallId: calls[0].Id}) {
+		t.Errorf("Expected a reminder and the call, got %+v", scheduled)
+	}
+
+	// The reminder was posted before the call, which announces the start.
+	setNow(srv, week1.Add(10*time.Hour+30*time.Minute))
+	messages := chatMessages(srv, channel.Id)
+	if len(messages) != 3 || !strings.HasPrefix(messages[1].Text, "Reminder:") ||
+		len(messages[2].Blocks) != 1 || messages[2].Blocks[0] != (client.CallBlock{CallId: calls[0].Id}) {
+		t.Errorf("Expected the call to be posted, got messages %+v", messages)
+	}
+
+	// A late re-run with a new link updates the call instead of posting
+	// another.
+	setConfig(t, func(c *Config) { c.Call.Url = "http://zoom/new" })
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	if calls = srv.Calls(); len(calls) != 1 || calls[0].JoinUrl != "http://zoom/new" {
+		t.Errorf("Expected the call to be updated, got %+v", calls)
+	}
+	if messages := chatMessages(srv, channel.Id); len(messages) != 3 {
+		t.Errorf("Expected no new messages, got %+v", messages)
+	}
+	setConfig(t, func(c *Config) { c.Call.Url = "http://zoom" })
+
+	// Some chatter, to be summarized before archiving.
+	chatter := srv.AddMessage(slacktest.Message{ChannelId: channel.Id, User: "U1", Text: "gg"})
+	srv.AddMessage(slacktest.Message{ChannelId: channel.Id, User: "U2", Text: "gg!", ThreadTs: chatter.Ts})
+
+	// Week 2: the new channel replaces last week's.
+	week2 := week1.AddDate(0, 0, 7)
+	setNow(srv, week2)
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+	if body := rr.Body.String(); !strings.Contains(body, "#20261006 had 1 messages and 1 replies from 2 people: alice, bob") {
+		t.Errorf("Expected a summary of the old channel, got body:\n%s", body)
+	}
+
+	if old, _ := srv.Channel("20261006"); !old.Archived {
+		t.Errorf("Old channel wasn't archived: %+v", old)
+	}
+	if calls = srv.Calls(); calls[0].DateEnd != week2.Unix() {
+		t.Errorf("Old call wasn't ended: %+v", calls[0])
+	}
+	channel, found = srv.Channel("20261013")
+	if !found || channel.Archived {
+		t.Fatalf("Channel #20261013 wasn't created. Channels: %+v", srv.Channels())
+	}
+
+	// A cron retry must not break anything, and invites whoever was missed.
+	srv.AddUser(client.User{Id: "U4", Name: "carol"})
+	rr = serveCron(t, CreateChannelHandler, "/create_channel")
+	if body := rr.Body.String(); !strings.Contains(body, "Invited 1 users, 2 already present, 0 failed") {
+		t.Errorf("Expected only carol to be invited, got body:\n%s", body)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Scheduled 0 announcements, kept 2, removed 0") {
+		t.Errorf("Expected the announcements to be kept, got body:\n%s", body)
+	}
+	// Slack rewrote the welcome message's text, but its link is unchanged.
+	if body := rr.Body.String(); !strings.Contains(body, "The welcome message is up to date") {
+		t.Errorf("Expected the welcome message to be kept, got body:\n%s", body)
+	}
+
+	if channels := srv.Channels(); len(channels) != 2 {
+		t.Errorf("Expected 2 channels, got %+v", channels)
+	}
+	if channel, _ = srv.Channel("20261013"); channel.Archived ||
+		!reflect.DeepEqual([]string{"UBOT", "U1", "U2", "U4"}, channel.Members) {
+		t.Errorf("Unexpected channel state after re-run: %+v", channel)
+	}
+}
+
+// Large workspaces are invited in concurrent batches.
+func TestConcurrentInviteBatches(t *testing.T) {
+	srv := startFakeSlack(t)
+	for i := 4; i < 20; i++ {
+		srv.AddUser(client.User{Id: fmt.Sprintf("U%d", i), Name: fmt.Sprintf("user%d", i)})
+	}
+	inviteBatchSize = 3
+	defer func() { inviteBatchSize = client.MaxInviteUsers }()
+	setConfig(t, func(c *Config) { c.InviteConcurrency = 4 })
+
+	setNow(srv, time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location()))
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+
+	if body := rr.Body.String(); !strings.Contains(body, "Invited 18 users, 0 already present, 0 failed") {
+		t.Errorf("Expected everyone to be invited, got body:\n%s", body)
+	}
+	if channel, _ := srv.Channel("20261006"); len(channel.Members) != 19 {
+		t.Errorf("Expected 19 members, got %v", channel.Members)
+	}
+}
+
+// Channels the janitor didn't create are never archived.
+func TestOnlyArchivesOwnChannels(t *testing.T) {
+	srv := startFakeSlack(t)
+	srv.AddChannel("20260929", "U1")
+
+	setNow(srv, time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location()))
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+
+	if old, _ := srv.Channel("20260929"); old.Archived {
+		t.Errorf("Archived a channel the janitor didn't create: %+v", old)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Not archiving #20260929") {
+		t.Errorf("Expected the channel to be skipped, got body:\n%s", body)
+	}
+}
+
+// A private rotation creates, finds and archives private channels.
+func TestPrivateRotation(t *testing.T) {
+	srv := startFakeSlack(t)
+	setConfig(t, func(c *Config) { c.PrivateChannels = true })
+
+	week1 := time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location())
+	setNow(srv, week1)
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	setNow(srv, week1.Add(10*time.Hour+30*time.Minute))
+
+	channel, _ := srv.Channel("20261006")
+	if !channel.Private || len(channel.Members) != 3 || len(chatMessages(srv, channel.Id)) != 3 {
+		t.Errorf("Expected a private channel with everyone, got %+v", channel)
+	}
+
+	setNow(srv, week1.AddDate(0, 0, 7))
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	if old, _ := srv.Channel("20261006"); !old.Archived {
+		t.Errorf("Old private channel wasn't archived: %+v", old)
+	}
+	if channel, _ := srv.Channel("20261013"); !channel.Private {
+		t.Errorf("Expected a private channel, got %+v", channel)
+	}
+}
+
+// Re-running an archived week, e.g. after a rollback, revives its channel.
+func TestRevivesArchivedChannel(t *testing.T) {
+	srv := startFakeSlack(t)
+
+	week1 := time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location())
+	setNow(srv, week1)
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	setNow(srv, week1.AddDate(0, 0, 7))
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	if old, _ := srv.Channel("20261006"); !old.Archived {
+		t.Fatalf("Expected #20261006 to be archived: %+v", old)
+	}
+
+	setNow(srv, week1)
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+
+	if channel, _ := srv.Channel("20261006"); channel.Archived {
+		t.Errorf("Expected #20261006 to be unarchived: %+v", channel)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Invited 0 users, 2 already present, 0 failed") {
+		t.Errorf("Expected the revived channel to be reused, got body:\n%s", body)
+	}
+	if channels := srv.Channels(); len(channels) != 2 {
+		t.Errorf("Expected no new channels, got %+v", channels)
+	}
+}
+
+// A same-named archived channel the janitor can't revive is replaced by
+// the fallback name, which later runs find.
+func TestFallbackChannelName(t *testing.T) {
+	srv := startFakeSlack(t)
+	srv.ArchiveChannel(srv.AddChannel("20261006", "U1"))
+
+	week1 := time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location())
+	setNow(srv, week1)
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	setNow(srv, week1.Add(10*time.Hour+30*time.Minute))
+
+	channel, found := srv.Channel("20261006-2")
+	if !found || len(channel.Members) != 3 || len(chatMessages(srv, channel.Id)) != 3 {
+		t.Fatalf("Expected #20261006-2 with everyone and the call, got %+v", srv.Channels())
+	}
+	if old, _ := srv.Channel("20261006"); !old.Archived {
+		t.Errorf("Expected #20261006 to stay archived: %+v", old)
+	}
+
+	// A re-run reuses the fallback channel.
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	if channels := srv.Channels(); len(channels) != 2 {
+		t.Errorf("Expected no new channels, got %+v", channels)
+	}
+
+	setNow(srv, week1.AddDate(0, 0, 7))
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	if old, _ := srv.Channel("20261006-2"); !old.Archived {
+		t.Errorf("Expected #20261006-2 to be archived: %+v", old)
+	}
+}
+
+// Channels named by week are found and archived by the same scheme, and
+// date-named channels are left alone.
+func TestWeeklyChannelNames(t *testing.T) {
+	srv := startFakeSlack(t)
+	useConfig(t, `
+rotation: gamenight
+channel_name:
+  format: "{{.Rotation}}-{{.ISOYear}}-w{{.Week}}"
+`)
+	srv.AddChannel("20261006", "UBOT")
+
+	week1 := time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location())
+	setNow(srv, week1)
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	setNow(srv, week1.Add(10*time.Hour+30*time.Minute))
+	if calls := srv.Calls(); len(calls) != 1 || calls[0].ExternalUniqueId != "gamenight-2026-w41" {
+		t.Errorf("Expected the call in #gamenight-2026-w41, got %+v", calls)
+	}
+
+	setNow(srv, week1.AddDate(0, 0, 7))
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	if channel, found := srv.Channel("gamenight-2026-w42"); !found || len(channel.Members) != 3 {
+		t.Errorf("Expected #gamenight-2026-w42 with everyone, got %+v", channel)
+	}
+	if old, _ := srv.Channel("gamenight-2026-w41"); !old.Archived {
+		t.Errorf("Expected #gamenight-2026-w41 to be archived: %+v", old)
+	}
+	if other, _ := srv.Channel("20261006"); other.Archived {
+		t.Errorf("Archived a channel outside the scheme: %+v", other)
+	}
+}
+
+// A biweekly rotation skips its off weeks, and archives the channel from
+// two weeks before.
+func TestBiweeklyRotation(t *testing.T) {
+	srv := startFakeSlack(t)
+	useConfig(t, `
+cadence:
+  every: biweekly
+  weekdays: [thursday]
+  start: "2026-10-01"
+`)
+
+	week1 := time.Date(2026, 10, 1, 8, 0, 0, 0, config.Location())
+	setNow(srv, week1)
+	serveCron(t, CreateChannelHandler, "/create_channel")
+
+	setNow(srv, week1.AddDate(0, 0, 7))
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+	if body := rr.Body.String(); !strings.Contains(body, "The next channel is on Thu Oct 15") {
+		t.Errorf("Expected the off week to be skipped, got body:\n%s", body)
+	}
+	rr = serveCron(t, PostCallHandler, "/post_call")
+	if calls := srv.Calls(); len(calls) != 1 || calls[0].ExternalUniqueId != "20261001" ||
+		!strings.Contains(rr.Body.String(), "No channel today") {
+		t.Errorf("Expected no call in the off week, got %+v", calls)
+	}
+	if channels := srv.Channels(); len(channels) != 1 {
+		t.Errorf("Expected only the first channel, got %+v", channels)
+	}
+
+	setNow(srv, week1.AddDate(0, 0, 14))
+	serveCron(t, CreateChannelHandler, "/create_channel")
+	if old, _ := srv.Channel("20261001"); !old.Archived {
+		t.Errorf("Expected #20261001 to be archived: %+v", old)
+	}
+	if _, found := srv.Channel("20261015"); !found {
+		t.Errorf("Expected #20261015, got %+v", srv.Channels())
+	}
+}
+
+// The sweeper archives every old channel the janitor created, including
+// ones missed by skipped runs, and reports the channels it left alone.
+func TestRetentionSweep(t *testing.T) {
+	srv := startFakeSlack(t)
+	useConfig(t, "retention: {keep: 2}")
+	srv.AddChannel("20260915", "U1")
+	srv.AddChannel("random", "U1")
+
+	// Runs that stopped before archiving anything.
+	week1 := time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location())
+	for week := 0; week < 3; week++ {
+		setNow(srv, week1.AddDate(0, 0, 7*week))
+		serveCron(t, CreateChannelHandler, "/create_channel?create_only")
+	}
+
+	setNow(srv, week1.AddDate(0, 0, 21))
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+
+	body := rr.Body.String()
+	for _, expected := range []string{
+		"Swept 5 channels: kept 2, archived 2, skipped 1",
+		"Archived: #20261013, #20261006",
+		"Skipped: #20260915 (not created by the janitor)",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q, got body:\n%s", expected, body)
+		}
+	}
+	for name, archived := range map[string]bool{
+		"20261027": false, "20261020": false, "20261013": true, "20261006": true,
+		"20260915": false, "random": false,
+	} {
+		if channel, _ := srv.Channel(name); channel.Archived != archived {
+			t.Errorf("Expected #%s archived to be %v: %+v", name, archived, channel)
+		}
+	}
+
+	// Nothing left to archive.
+	rr = serveCron(t, CreateChannelHandler, "/create_channel")
+	if body := rr.Body.String(); !strings.Contains(body, "Swept 3 channels: kept 2, archived 0, skipped 1") {
+		t.Errorf("Expected nothing to be archived, got body:\n%s", body)
+	}
+}
+
+// A re-run with a new link updates the pinned welcome message and the
+// bookmark in place, and replaces the scheduled announcements rather than
+// adding more.
+func TestRerunWithNewLink(t *testing.T) {
+	srv := startFakeSlack(t)
+	setConfig(t, func(c *Config) {
+		c.Links = []channelLink{{"Rules", "http://rules"}, {"Scores", "http://scores"}}
+		c.Reminders = []time.Duration{2 * time.Hour, time.Hour}
+	})
+
+	setNow(srv, time.Date(2026, 10, 6, 8, 0, 0, 0, config.Location()))
+	serveCron(t, CreateChannelHandler, "/create_channel")
+
+	channel, _ := srv.Channel("20261006")
+	welcome := chatMessages(srv, channel.Id)
+	if len(welcome) != 1 || !welcome[0].Pinned {
+		t.Fatalf("Expected a pinned welcome message, got %+v", welcome)
+	}
+	if bookmarks := srv.Bookmarks(channel.Id); len(bookmarks) != 3 || bookmarks[0].Link != "http://zoom" ||
+		bookmarks[1].Title != "Rules" || bookmarks[2].Link != "http://scores" {
+		t.Errorf("Expected the links to be bookmarked, got %+v", bookmarks)
+	}
+
+	setConfig(t, func(c *Config) { c.Call.Url = "http://zoom/new" })
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+	if body := rr.Body.String(); !strings.Contains(body, "Scheduled 3 announcements, kept 0, removed 3") {
+		t.Errorf("Expected the announcements to be replaced, got body:\n%s", body)
+	}
+
+	if body := rr.Body.String(); !strings.Contains(body, "Bookmarks: 0 added, 1 edited, 0 removed") {
+		t.Errorf("Expected the video call bookmark to be edited, got body:\n%s", body)
+	}
+	if bookmarks := srv.Bookmarks(channel.Id); len(bookmarks) != 3 || bookmarks[0].Link != "http://zoom/new" {
+		t.Errorf("Expected the new link to be bookmarked, got %+v", bookmarks)
+	}
+	if messages := chatMessages(srv, channel.Id); len(messages) != 1 || messages[0].Ts != welcome[0].Ts ||
+		!messages[0].Edited || !strings.Contains(messages[0].Text, "http://zoom/new") {
+		t.Errorf("Expected the welcome message to be updated, got %+v", messages)
+	}
+
+	scheduled := srv.ScheduledMessages(channel.Id)
+	if len(scheduled) != 3 {
+		t.Fatalf("Expected 3 scheduled messages, got %+v", scheduled)
+	}
+	for _, msg := range scheduled {
+		if !strings.Contains(msg.Text, "http://zoom/new") {
+			t.Errorf("Expected the new link, got %q", msg.Text)
+		}
+	}
+}
+
+// A channel created after the call started gets the call right away.
+func TestLateRotationPostsCall(t *testing.T) {
+	srv := startFakeSlack(t)
+
+	setNow(srv, time.Date(2026, 10, 6, 19, 0, 0, 0, config.Location()))
+	rr := serveCron(t, CreateChannelHandler, "/create_channel")
+
+	channel, _ := srv.Channel("20261006")
+	calls := srv.Calls()
+	messages := chatMessages(srv, channel.Id)
+	if len(calls) != 1 || len(messages) != 2 || len(messages[1].Blocks) != 1 ||
+		messages[1].Blocks[0] != (client.CallBlock{CallId: calls[0].Id}) {
+		t.Errorf("Expected the call to be posted, got calls %+v and messages %+v", calls, messages)
+	}
+	if scheduled := srv.ScheduledMessages(channel.Id); len(scheduled) != 0 {
+		t.Errorf("Expected nothing to be scheduled, got %+v", scheduled)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Scheduled 0 announcements, kept 0, removed 0") {
+		t.Errorf("Unexpected body:\n%s", body)
+	}
+}
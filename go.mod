@@ -5,4 +5,9 @@ go 1.16
 require (
 	github.com/golang/mock v1.4.4
 	github.com/jaywhyzed/slackJanitor/client v0.9.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+// The janitor builds against the client in this repo, see "Client Module" in
+// README.md. The version above is the last published one.
+replace github.com/jaywhyzed/slackJanitor/client => ./client
@@ -0,0 +1,160 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// inviteResult is the outcome of inviting users to a channel.
+type inviteResult struct {
+	Invited        []string
+	AlreadyPresent []string
+	// Failed lists the users Slack refused to invite, and why.
+	Failed []client.InviteError
+}
+
+func (r inviteResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Invited %d users, %d already present, %d failed",
+		len(r.Invited), len(r.AlreadyPresent), len(r.Failed))
+	for _, failure := range r.Failed {
+		fmt.Fprintf(&b, "\n  %s: %s", failure.User, failure.Error)
+	}
+	return b.String()
+}
+
+// getChannelMembers returns the IDs of the channel's members.
+func getChannelMembers(ctx context.Context, channelId string) (map[string]bool, error) {
+	members := map[string]bool{}
+
+	var members_resp client.ConversationMembersResponse
+	err := Paginate(ctx, client.ConversationMembersRequest{ChannelId: channelId}, &members_resp,
+		func() error {
+			for _, member := range members_resp.Members {
+				members[member] = true
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("Error listing the channel's members: %w", err)
+	}
+
+	return members, nil
+}
+
+// inviteBatchSize is the most users invited per request. Overridden by
+// tests.
+var inviteBatchSize = client.MaxInviteUsers
+
+// inviteUsers invites the users who aren't in the channel yet, so a
+// re-run after a partial failure invites whoever was missed. Users Slack
+// refuses are reported in the result, and don't stop the others being
+// invited. Returns the first batch's error if a request couldn't be made.
+func inviteUsers(ctx context.Context, channelId string, users []client.User) (inviteResult, error) {
+	var result inviteResult
+	members, err := getChannelMembers(ctx, channelId)
+	if err != nil {
+		return result, err
+	}
+
+	var missing []string
+	for _, user := range users {
+		if members[user.Id] {
+			result.AlreadyPresent = append(result.AlreadyPresent, user.Id)
+		} else {
+			missing = append(missing, user.Id)
+		}
+	}
+
+	var batches [][]string
+	for len(missing) > 0 {
+		size := inviteBatchSize
+		if size > len(missing) {
+			size = len(missing)
+		}
+		batches = append(batches, missing[:size])
+		missing = missing[size:]
+	}
+
+	// Batches start in order. With an InviteConcurrency of 1, each one also
+	// finishes before the next starts. Either way, the client's rate limiter
+	// applies, and the results are combined in batch order.
+	results := make([]inviteResult, len(batches))
+	errs := make([]error, len(batches))
+	running := make(chan struct{}, config.InviteConcurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		running <- struct{}{}
+		wg.Add(1)
+		go func(i int, batch []string) {
+			defer wg.Done()
+			defer func() { <-running }()
+			results[i], errs[i] = inviteBatch(ctx, channelId, batch)
+		}(i, batch)
+	}
+	wg.Wait()
+
+	for i := range batches {
+		if errs[i] != nil {
+			return result, errs[i]
+		}
+		result.Invited = append(result.Invited, results[i].Invited...)
+		result.AlreadyPresent = append(result.AlreadyPresent, results[i].AlreadyPresent...)
+		result.Failed = append(result.Failed, results[i].Failed...)
+	}
+	return result, nil
+}
+
+// inviteBatch invites up to client.MaxInviteUsers users. Users Slack
+// refuses are reported in the result. Slack refusing the whole request,
+// e.g. because the channel is archived, is returned as an error.
+func inviteBatch(ctx context.Context, channelId string, users []string) (inviteResult, error) {
+	var result inviteResult
+
+	var invite_resp client.InviteResponse
+	_, err := Execute(ctx,
+		client.ConversationInvite{ChannelId: channelId, Users: users, Force: true},
+		&invite_resp)
+	var slackErr *client.SlackError
+	if err != nil && !errors.As(err, &slackErr) {
+		return result, fmt.Errorf("Error inviting users: %w", err)
+	}
+	// Without per-user errors, the request failed for everyone.
+	if slackErr != nil && len(invite_resp.Errors) == 0 {
+		if slackErr.Code != "already_in_channel" {
+			return result, fmt.Errorf("Error inviting users: %w", err)
+		}
+		for _, user := range users {
+			invite_resp.Errors = append(invite_resp.Errors, client.InviteError{User: user, Error: slackErr.Code})
+		}
+	}
+
+	refused := map[string]bool{}
+	for _, failure := range invite_resp.Errors {
+		refused[failure.User] = true
+		if failure.Error == "already_in_channel" {
+			// Joined since we listed the members.
+			result.AlreadyPresent = append(result.AlreadyPresent, failure.User)
+		} else {
+			result.Failed = append(result.Failed, failure)
+		}
+	}
+
+	for _, user := range users {
+		if refused[user] {
+			continue
+		}
+		if slackErr != nil {
+			// Not refused itself, but not invited since the request failed.
+			result.Failed = append(result.Failed, client.InviteError{User: user, Error: slackErr.Code})
+		} else {
+			result.Invited = append(result.Invited, user)
+		}
+	}
+	return result, nil
+}
@@ -1,68 +1,118 @@
 package janitor
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jaywhyzed/slackJanitor/client"
+	"github.com/jaywhyzed/slackJanitor/client/cassette"
 )
 
-// A time.Location representing California.
-var CaliforniaLocation *time.Location
 var slackClient client.Client
 var requireCron bool = false
 
-// Initialize the client if necessary, and all Execute.
-func Execute(req client.Request, resp interface{}) (string, error) {
-	// Clear the response.
-	p := reflect.ValueOf(resp).Elem()
-	p.Set(reflect.Zero(p.Type()))
+// now returns the current time. Overridden by tests.
+var now = time.Now
 
+// Initialize the client if necessary.
+func getSlackClient() (client.Client, error) {
 	if slackClient == nil {
 		token := os.Getenv("SLACK_BOT_USER_TOKEN")
 		if len(token) == 0 {
-			log.Fatal("Missing token!")
+			return nil, errMissingToken
+		}
+		var opts []client.Option
+		// Allows running against a local or staging Slack compatible API.
+		if baseURL := os.Getenv("SLACK_API_URL"); len(baseURL) > 0 {
+			opts = append(opts, client.WithBaseURL(baseURL))
+		}
+		var httpClient client.HttpClientInterface = &http.Client{}
+		// Records all Slack traffic, for replaying in tests.
+		if path := os.Getenv("SLACK_RECORD_CASSETTE"); len(path) > 0 {
+			httpClient = cassette.NewFileRecorder(httpClient, path)
 		}
-		slackClient = client.NewClient(token)
+		slackClient = client.NewClientWithHttpClient(httpClient, token, opts...)
 	}
-	return slackClient.Execute(req, resp)
+	return slackClient, nil
 }
 
-// Like Execute() but dies on underlying failure.
-func ExecuteOrDie(req client.Request, resp interface{}) string {
-	respText, err := Execute(req, resp)
+// Initialize the client if necessary, and call Execute.
+// Slack refusing the request returns a *client.SlackError.
+func Execute(ctx context.Context, req client.Request, resp interface{}) (string, error) {
+	// Clear the response.
+	p := reflect.ValueOf(resp).Elem()
+	p.Set(reflect.Zero(p.Type()))
+
+	c, err := getSlackClient()
 	if err != nil {
-		log.Fatalf("Encountered error: %s\nHandling request:\n%+v\nResponse text:\n%s",
+		return "", err
+	}
+	respText, err := c.ExecuteContext(ctx, req, resp)
+	if err != nil && !isSlackError(err) {
+		log.Printf("Encountered error: %s\nHandling request:\n%+v\nResponse text:\n%s",
 			err, req, respText)
 	}
-	return respText
+	return respText, err
 }
 
-func init() {
-	var err error
-	CaliforniaLocation, err = time.LoadLocation("America/Los_Angeles")
+// Initialize the client if necessary, and call client.Paginate().
+func Paginate(ctx context.Context, req client.PaginatedRequest,
+	resp client.PaginatedResponse, fn func() error) error {
+	c, err := getSlackClient()
 	if err != nil {
-		log.Fatalf("Got error loading location: %+v\n", err)
+		return err
+	}
+	err = client.Paginate(ctx, c, req, resp, fn)
+	if err != nil && !isSlackError(err) {
+		log.Printf("Encountered error: %s\nPaginating request:\n%+v", err, req)
 	}
+	return err
 }
 
-// timeAsChannelName converts a time.Time to the name of a channel.
-func timeAsChannelName(t time.Time) string {
-	return t.Format("20060102")
+// The name of the new channel, to be created.
+func newChannelName() (string, error) {
+	name, err := config.ChannelName(now())
+	if err != nil {
+		return "", misconfigured(err)
+	}
+	return name, nil
 }
 
-// The name of the new channel, to be created.
-func newChannelName() string {
-	return timeAsChannelName(time.Now().In(CaliforniaLocation))
+// The name of the old channel, to be archived: the one before today's on
+// the cadence. Returns "" if there isn't one.
+func oldChannelName() (string, error) {
+	previous, found := config.Cadence.Previous(now().In(config.Location()))
+	if !found {
+		return "", nil
+	}
+	name, err := config.ChannelName(previous)
+	if err != nil {
+		return "", misconfigured(err)
+	}
+	return name, nil
 }
 
-// The name of the old channel, to be archived.
-func oldChannelName() string {
-	return timeAsChannelName(time.Now().AddDate(0, 0, -7).In(CaliforniaLocation))
+// rotatesToday reports whether today has a channel. Otherwise, the run is
+// skipped, so cron can call the handlers every day.
+func rotatesToday(w io.Writer) bool {
+	today := now().In(config.Location())
+	if config.Cadence.On(today) {
+		return true
+	}
+	log.Printf("No channel on %s", today.Format("Mon Jan 2"))
+	fmt.Fprintf(w, "No channel today, skipping\n")
+	if next, found := config.Cadence.next(today); found {
+		fmt.Fprintf(w, "The next channel is on %s\n", next.Format("Mon Jan 2"))
+	}
+	return false
 }
 
 // IndexHandler responds to requests with our greeting.
@@ -74,72 +124,266 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, "Hello, World!\n")
 }
 
-// createChannelOrDie attempts to create a Slack channel with the given name,
-// and returns the API response.
-// Dies on HTTP error.
-func createChannelOrDie(name string) client.ChannelResponse {
+// rotationChannelList lists the unarchived channels of the rotation's type.
+func rotationChannelList() client.ChannelListRequest {
+	if config.PrivateChannels {
+		return client.ChannelListRequest{Types: []string{client.PrivateChannel}}
+	}
+	return client.ChannelListRequest{}
+}
+
+// createChannel attempts to create a Slack channel with the given name,
+// and returns it. Returns nil if the name is already taken.
+func createChannel(ctx context.Context, name string) (*client.Channel, error) {
 	var channel_resp client.ChannelResponse
-	json_str := ExecuteOrDie(client.CreateChannelRequest{Name: name}, &channel_resp)
-	if channel_resp.Ok == false {
-		log.Printf("Error creating channel? Response:\n%s\n", json_str)
+	_, err := Execute(ctx,
+		client.CreateChannelRequest{Name: name, IsPrivate: config.PrivateChannels}, &channel_resp)
+	if client.IsNameTaken(err) {
+		log.Printf("Channel #%s already exists", name)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Error creating channel #%s: %w", name, err)
 	}
 	log.Printf("Created Channel:\n%v", channel_resp)
-	return channel_resp
+	return &channel_resp.Channel, nil
 }
 
-// getNonBotUsersOrDie calls the Slack API to get a list of non-bot Users.
-// Dies on HTTP error.
-func getNonBotUsersOrDie() []client.User {
-	users_req := client.UsersListRequest{}
-	users := make([]client.User, 0)
+// fallbackChannelName is the name used instead of name when it's taken by a
+// channel that can't be revived. An empty suffix turns the fallback off.
+func fallbackChannelName(name string) string {
+	return name + config.FallbackSuffix
+}
 
-	for ok := true; ok == true; ok = len(users_req.Cursor) > 0 {
-		var users_resp client.UsersListResponse
-		json_str := ExecuteOrDie(users_req, &users_resp)
-		if users_resp.Ok == false {
-			log.Fatalf("Error getting non-bot users:\n%s", json_str)
+// createOrReuseChannel creates the channel, or fetches it if it already
+// exists, e.g. on a re-run. A same-named archived channel, e.g. after a
+// rollback, is unarchived. If that fails, the fallback name is used instead.
+// Returns channelNotFound if no channel could be created or found.
+func createOrReuseChannel(ctx context.Context, w io.Writer, name string) (*client.Channel, error) {
+	channel, err := createChannel(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if channel != nil {
+		fmt.Fprintf(w, "Created channel:\n%+v\n", *channel)
+		return channel, nil
+	}
+
+	fmt.Fprintf(w, "Channel already exists, fetching it...\n")
+	log.Printf("Fetching existing channel...")
+	channel, err = findChannel(ctx, name, true)
+	switch {
+	case err != nil:
+		return nil, err
+	case channel == nil:
+		// e.g. a private channel the bot isn't in.
+		log.Printf("Can't find the channel #%s!", name)
+	case !channel.IsArchived:
+		fmt.Fprintf(w, "Fetched channel:\n%+v\n", *channel)
+		return channel, nil
+	default:
+		fmt.Fprintf(w, "Channel #%s is archived, unarchiving it...\n", name)
+		unarchive_resp := client.GenericResponse{}
+		_, err := Execute(ctx, client.ChannelUnarchiveRequest{ChannelId: channel.Id}, &unarchive_resp)
+		if err == nil {
+			channel.IsArchived = false
+			fmt.Fprintf(w, "Unarchived channel:\n%+v\n", *channel)
+			return channel, nil
+		} else if !isSlackError(err) {
+			return nil, err
 		}
+		log.Printf("Can't unarchive #%s:\n%v", name, err)
+	}
 
+	fallback := fallbackChannelName(name)
+	if fallback == name {
+		return nil, channelNotFound(name)
+	}
+	fmt.Fprintf(w, "Can't use #%s, using #%s instead\n", name, fallback)
+	if channel, err := createChannel(ctx, fallback); err != nil || channel != nil {
+		if channel != nil {
+			fmt.Fprintf(w, "Created channel:\n%+v\n", *channel)
+		}
+		return channel, err
+	}
+	// Created by an earlier run.
+	channel, err = getChannel(ctx, fallback)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, channelNotFound(fallback)
+	}
+	fmt.Fprintf(w, "Fetched channel:\n%+v\n", *channel)
+	return channel, nil
+}
+
+// getNonBotUsers calls the Slack API to get a list of the people in the
+// workspace, leaving out bots, app users, Slackbot, guests and deleted users.
+func getNonBotUsers(ctx context.Context) ([]client.User, error) {
+	users := make([]client.User, 0)
+
+	var users_resp client.UsersListResponse
+	err := Paginate(ctx, client.UsersListRequest{}, &users_resp, func() error {
 		for _, user := range users_resp.Members {
-			if user.IsBot == false && user.Deleted == false {
+			if user.IsPerson() {
 				users = append(users, user)
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error listing users: %w", err)
+	}
 
-		users_req.Cursor = users_resp.Metadata.NextCursor
+	return users, nil
+}
+
+// userNames returns the names people see in Slack for the users, in order.
+// Users Slack can't look up are shown by their ID.
+func userNames(ctx context.Context, userIds []string) ([]string, error) {
+	names := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		var user_resp client.UserResponse
+		_, err := Execute(ctx, client.UsersInfoRequest{UserId: userId}, &user_resp)
+		if isSlackError(err) {
+			log.Printf("Can't look up %s, showing the ID:\n%v", userId, err)
+			names = append(names, userId)
+		} else if err != nil {
+			return nil, err
+		} else {
+			names = append(names, user_resp.User.DisplayName())
+		}
 	}
+	return names, nil
+}
 
-	return users
+// getBotUserId returns the ID of the bot user the token belongs to.
+func getBotUserId(ctx context.Context) (string, error) {
+	var auth_resp client.AuthTestResponse
+	if _, err := Execute(ctx, client.AuthTestRequest{}, &auth_resp); err != nil {
+		return "", err
+	}
+	return auth_resp.UserId, nil
 }
 
 // May return nil if channel not found
-func getChannelOrDie(name string) *client.Channel {
-	channels_req := client.ChannelListRequest{}
-	channels_resp := client.ChannelListResponse{}
+func getChannel(ctx context.Context, name string) (*client.Channel, error) {
+	return findChannel(ctx, name, false)
+}
 
-	for ok := true; ok == true; ok = len(channels_req.Cursor) > 0 {
-		log.Printf("Executing ChannelListRequest...")
-		ExecuteOrDie(channels_req, &channels_resp)
-		if channels_resp.Ok != true {
-			log.Fatalf("Channels resp error:\n%+v", channels_resp)
-		}
+// getRotationChannel finds the unarchived channel with the given name,
+// or with the fallback suffix if the name couldn't be used.
+// May return nil if neither is found.
+func getRotationChannel(ctx context.Context, name string) (*client.Channel, error) {
+	channel, err := getChannel(ctx, name)
+	if err != nil || channel != nil {
+		return channel, err
+	}
+	if fallback := fallbackChannelName(name); fallback != name {
+		return getChannel(ctx, fallback)
+	}
+	return nil, nil
+}
+
+// findChannel looks up a channel of the rotation's type by name,
+// including archived ones if includeArchived is set.
+// May return nil if channel not found
+func findChannel(ctx context.Context, name string, includeArchived bool) (*client.Channel, error) {
+	var found *client.Channel
+
+	req := rotationChannelList()
+	req.IncludeArchived = includeArchived
+	channels_resp := client.ChannelListResponse{}
+	log.Printf("Executing ChannelListRequest...")
+	err := Paginate(ctx, req, &channels_resp, func() error {
 		for _, channel := range channels_resp.Channels {
 			if channel.Name == name {
-				return &channel
+				found = &channel
+				return client.ErrStopPagination
 			}
 		}
-		channels_req.Cursor = channels_resp.Metadata.NextCursor
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error looking up channel #%s: %w", name, err)
+	}
+
+	if found == nil {
+		log.Printf("Couldn't find channel #%s", name)
+	}
+	return found, nil
+}
+
+// summarizeChannel reads the channel's history, and writes how active it
+// was. Messages from bots, including ours, aren't counted. Returns the ID of
+// the latest Call the janitor posted, found on the way, or "".
+func summarizeChannel(ctx context.Context, w io.Writer, channel *client.Channel, botUserId string) (string, error) {
+	messages, replies := 0, 0
+	people := map[string]bool{}
+	callId := ""
+
+	var history_resp client.MessagesResponse
+	err := Paginate(ctx, client.ConversationsHistoryRequest{ChannelId: channel.Id},
+		&history_resp, func() error {
+			for _, msg := range history_resp.Messages {
+				// History is newest first.
+				if len(callId) == 0 {
+					callId = messageCallId(msg, botUserId)
+				}
+				replies += msg.ReplyCount
+				for _, user := range msg.ReplyUsers {
+					people[user] = true
+				}
+				// Skip the bot's messages, and system messages like
+				// channel_join.
+				if len(msg.BotId) == 0 && len(msg.Subtype) == 0 {
+					messages++
+					people[msg.User] = true
+				}
+			}
+			return nil
+		})
+	if isSlackError(err) {
+		log.Printf("Can't read the history of #%s, ignoring:\n%v", channel.Name, err)
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	userIds := make([]string, 0, len(people))
+	for userId := range people {
+		userIds = append(userIds, userId)
+	}
+	sort.Strings(userIds)
+	names, err := userNames(ctx, userIds)
+	if err != nil {
+		return "", err
 	}
 
-	log.Printf("Couldn't find channel #%s", name)
-	return nil
+	log.Printf("#%s had %d messages and %d replies from %d people: %v",
+		channel.Name, messages, replies, len(people), userIds)
+	fmt.Fprintf(w, "#%s had %d messages and %d replies from %d people",
+		channel.Name, messages, replies, len(people))
+	if len(names) > 0 {
+		fmt.Fprintf(w, ": %s", strings.Join(names, ", "))
+	}
+	fmt.Fprintln(w)
+	return callId, nil
 }
 
 // CreateChannelHandler handles the /create_channel URL.
 // Create a new channel.
 // Set a topic.
+// Bookmark the video call and the other configured links.
 // Add all non bot users to the new channel.
-// Archive the old channel.
+// Post and pin the welcome message, or update it.
+// Schedule the call's reminders, and its Call to be posted when it starts.
+// If the Call was already posted, update it to match the config instead.
+// Summarize the previous channel on the cadence, end its Call and archive
+// it, if the janitor created it. With retention configured, do that for
+// every channel but the newest ones instead.
+// Skipped on days without a channel.
+// Failures answer with a JSON Failure, see runSteps.
 func CreateChannelHandler(w http.ResponseWriter, r *http.Request) {
 	is_cron := r.Header.Get("X-Appengine-Cron")
 	log.Printf("Called from Appengine-Cron: %v\n", is_cron)
@@ -158,99 +402,233 @@ func CreateChannelHandler(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
+	_, createOnly := r.URL.Query()["create_only"]
+	runSteps(w, r, func(ctx context.Context, w io.Writer) error {
+		return rotateChannels(ctx, w, createOnly)
+	})
+}
+
+// rotateChannels runs the steps of CreateChannelHandler.
+func rotateChannels(ctx context.Context, w io.Writer, createOnly bool) error {
 	fmt.Fprint(w, "Hello, World!\n")
+	if !rotatesToday(w) {
+		return nil
+	}
 
-	channel_resp := createChannelOrDie(newChannelName())
-	fmt.Fprintf(w, "Got ChannelCreate Response:%+v\n", channel_resp)
-
-	channel := &channel_resp.Channel
-
-	if channel_resp.Ok == false {
-		log.Printf("Failed to create channel: %+v", channel_resp)
-		fmt.Fprintf(w, "Failed to create channel: %+v", channel_resp)
-		if channel_resp.Error == "name_taken" {
-			fmt.Fprintf(w, "Channel already exists, fetching it...\n")
-			log.Printf("Fetching existing channel...")
-			channel = getChannelOrDie(newChannelName())
-			if channel != nil {
-				fmt.Fprintf(w, "Fetched channel:\n%+v\n", *channel)
-			} else {
-				log.Printf("Can't find the channel #%s!", newChannelName())
-				http.NotFound(w, r)
-				return
-			}
-		}
+	name, err := newChannelName()
+	if err != nil {
+		return err
+	}
+	channel, err := createOrReuseChannel(ctx, w, name)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Setting topic...")
 	fmt.Fprintf(w, "Setting topic...\n")
 	set_topic_resp := client.GenericResponse{}
-	ExecuteOrDie(client.ChannelSetTopicRequest{
+	_, err = Execute(ctx, client.ChannelSetTopicRequest{
 		ChannelId: channel.Id,
-		Topic:     "Video Call: " + os.Getenv("VC_URL"),
+		Topic:     "Video Call: " + config.Call.Url,
 	},
 		&set_topic_resp)
-	if !set_topic_resp.Ok {
-		log.Printf("Failed to set topic.")
+	if isSlackError(err) {
+		log.Printf("Failed to set topic: %v", err)
+	} else if err != nil {
+		return err
+	}
+
+	botUserId, err := getBotUserId(ctx)
+	if err != nil {
+		return err
+	}
+	if err := syncBookmarks(ctx, w, channel.Id, botUserId); err != nil {
+		return err
 	}
 
-	if _, ok := r.URL.Query()["create_only"]; ok {
+	if createOnly {
 		log.Printf("create_only is specified, skipping user invitation and cleanup")
 		fmt.Fprintf(w, "Skipping user invitation b/c create_only was specified\n")
-		return
+		return nil
 	}
 
 	log.Printf("Getting Users")
-	users := getNonBotUsersOrDie()
+	users, err := getNonBotUsers(ctx)
+	if err != nil {
+		return err
+	}
 
 	log.Printf("Got %d Users", len(users))
 
-	invitation := client.ConversationInvite{ChannelId: channel.Id}
-
-	for _, user := range users {
-		invitation.Users = append(invitation.Users, user.Id)
+	fmt.Fprintf(w, "Sending invitation to new channel...\n")
+	result, err := inviteUsers(ctx, channel.Id, users)
+	if err != nil {
+		return err
 	}
+	log.Printf("%v", result)
+	fmt.Fprintf(w, "%v\n", result)
 
-	invite_response := client.ChannelResponse{}
-	fmt.Fprintf(w, "Sending invitation to new channel...\n")
-	json_resp := ExecuteOrDie(invitation, &invite_response)
-	if !invite_response.Ok {
-		// Invitation will fail if users are already added, not idempotent. Just ignore.
-		log.Printf("Invitation failed! Ignoring.\n%+v\n%s", invite_response, json_resp)
+	if err := postWelcomeMessage(ctx, w, channel.Id, botUserId); err != nil {
+		return err
 	}
 
-	post_resp := client.GenericResponse{}
-	ExecuteOrDie(
-		client.PostMessageRequest{
-			ChannelId: channel.Id,
-			Text:      fmt.Sprintf("Hello, welcome to today's channel.\nOur new video call link is %s", os.Getenv("VC_URL"))},
-		&post_resp)
+	posted, err := updatePostedCall(ctx, w, channel, botUserId)
+	if err != nil {
+		return err
+	}
+	if err := scheduleAnnouncements(ctx, w, channel, !posted); err != nil {
+		return err
+	}
 
-	old_channel := getChannelOrDie(oldChannelName())
+	if config.Retention.Keep > 0 {
+		return sweepChannels(ctx, w, botUserId)
+	}
+	old_name, err := oldChannelName()
+	if err != nil {
+		return err
+	}
+	if len(old_name) == 0 {
+		fmt.Fprintf(w, "No earlier channel to archive\n")
+		return nil
+	}
+	old_channel, err := getRotationChannel(ctx, old_name)
+	if err != nil {
+		return err
+	}
 	if old_channel == nil {
-		fmt.Fprintf(w, "Couldn't find old channel #%s\n", oldChannelName())
-	} else {
-		fmt.Fprintf(w, "Attempting to archive old channel.\n")
-		archive_resp := client.GenericResponse{}
-		ExecuteOrDie(client.ChannelArchiveRequest{ChannelId: old_channel.Id}, &archive_resp)
-
-		if !archive_resp.Ok {
-			log.Printf("Archive failed, ignoring:\n%+v", archive_resp)
+		fmt.Fprintf(w, "Couldn't find old channel #%s\n", old_name)
+		return nil
+	}
+	_, err = retireChannel(ctx, w, old_channel, botUserId)
+	return err
+}
+
+// welcomeMessage announces the new channel and its video call link.
+func welcomeMessage(channelId string, vcUrl string) client.PostMessageRequest {
+	message := client.PostMessageRequest{
+		ChannelId: channelId,
+		Text:      fmt.Sprintf("%s\nOur new video call link is %s", config.Welcome.Text, vcUrl),
+	}
+	blocks, err := client.NewBlockBuilder().
+		Header(config.Welcome.Header).
+		Markdown(fmt.Sprintf("Our new video call link is %s", vcUrl)).
+		Actions(client.LinkButton("join_call", "Join the Video Call", vcUrl)).
+		Build()
+	if err != nil {
+		// e.g. an overlong header or URL. The plain text still works.
+		log.Printf("Invalid welcome blocks, sending text only:\n%v", err)
+		return message
+	}
+	message.Blocks = blocks
+	return message
+}
+
+// findWelcomeMessage returns the latest welcome message the janitor posted
+// in the channel, or nil.
+func findWelcomeMessage(ctx context.Context, channelId string, botUserId string) (*client.Message, error) {
+	var found *client.Message
+
+	var history_resp client.MessagesResponse
+	err := Paginate(ctx, client.ConversationsHistoryRequest{ChannelId: channelId}, &history_resp,
+		func() error {
+			for _, msg := range history_resp.Messages {
+				// Slack stores the text escaped.
+				if msg.User == botUserId && strings.HasPrefix(msg.Text, client.EscapeText(config.Welcome.Text)) {
+					found = &msg
+					return client.ErrStopPagination
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("Error looking for the welcome message: %w", err)
+	}
+	return found, nil
+}
+
+// joinCallUrl returns the URL of the join_call button in blocks, or "".
+// Unlike the text, Slack keeps the blocks as they were posted.
+func joinCallUrl(blocks client.Blocks) string {
+	for _, block := range blocks {
+		actions, ok := block.(client.ActionsBlock)
+		if !ok {
+			continue
+		}
+		for _, element := range actions.Elements {
+			if button, ok := element.(client.ButtonElement); ok && button.ActionId == "join_call" {
+				return button.Url
+			}
+		}
+	}
+	return ""
+}
+
+// postWelcomeMessage posts the welcome message and pins it. On re-runs, the
+// message already posted is updated in place if the VC link changed.
+func postWelcomeMessage(ctx context.Context, w io.Writer, channelId string, botUserId string) error {
+	message := welcomeMessage(channelId, config.Call.Url)
+
+	var ts string
+	existing, err := findWelcomeMessage(ctx, channelId, botUserId)
+	switch {
+	case err != nil:
+		return err
+	case existing == nil:
+		var post_resp client.PostMessageResponse
+		if _, err := Execute(ctx, message, &post_resp); err != nil {
+			return fmt.Errorf("Error posting the welcome message: %w", err)
+		}
+		ts = post_resp.Ts
+		fmt.Fprintf(w, "Posted the welcome message\n")
+	// Text only messages, see welcomeMessage, are always updated.
+	case len(message.Blocks) > 0 && joinCallUrl(existing.Blocks) == joinCallUrl(message.Blocks):
+		ts = existing.Ts
+		fmt.Fprintf(w, "The welcome message is up to date\n")
+	default:
+		ts = existing.Ts
+		var update_resp client.PostMessageResponse
+		_, err := Execute(ctx, client.UpdateMessageRequest{
+			ChannelId: channelId,
+			Ts:        ts,
+			Text:      message.Text,
+			Blocks:    message.Blocks,
+		}, &update_resp)
+		if isSlackError(err) {
+			log.Printf("Updating the welcome message failed, ignoring:\n%v", err)
+		} else if err != nil {
+			return err
 		} else {
-			log.Printf("Archive done.")
+			fmt.Fprintf(w, "Updated the welcome message\n")
 		}
 	}
+
+	if existing != nil && existing.IsPinned(channelId) {
+		return nil
+	}
+	var pin_resp client.GenericResponse
+	_, err = Execute(ctx, client.PinsAddRequest{ChannelId: channelId, Timestamp: ts}, &pin_resp)
+	if isSlackError(err) {
+		if !client.IsSlackError(err, "already_pinned") {
+			log.Printf("Pinning the welcome message failed, ignoring:\n%v", err)
+		}
+		return nil
+	}
+	return err
 }
 
-func todayAtSixThirty() time.Time {
-	year, month, day := time.Now().In(CaliforniaLocation).Date()
-	return time.Date(year, month, day, 18, 30, 0, 0, CaliforniaLocation)
+// todayCallStart is when today's call starts.
+func todayCallStart() time.Time {
+	return config.CallStart(now())
 }
 
-// PostCallHandler handles the /post_call URL.
-// Create a Call object for the video call.
+// PostCallHandler handles the /post_call URL, to post the Call before it
+// starts. CreateChannelHandler schedules it otherwise.
 // Get the new Channel.
-// Post the Call to the Channel.
+// If its Call was already posted, update it to match the config.
+// Otherwise create a Call object for the video call, post it to the
+// Channel, and unschedule the one CreateChannelHandler scheduled.
+// Skipped on days without a channel.
+// Failures answer with a JSON Failure, see runSteps.
 func PostCallHandler(w http.ResponseWriter, r *http.Request) {
 	is_cron := r.Header.Get("X-Appengine-Cron")
 	log.Printf("Called from Appengine-Cron: %v\n", is_cron)
@@ -267,31 +645,38 @@ func PostCallHandler(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	call := client.Call{
-		ExternalUniqueId:  newChannelName(),
-		JoinUrl:           os.Getenv("VC_URL"),
-		ExternalDisplayId: os.Getenv("VC_CALL_ID"),
-		Title:             "Game Time!",
-		StartTimeUnix:     todayAtSixThirty().Unix(),
-	}
-
-	var callResp client.CallResponse
-	ExecuteOrDie(call, &callResp)
-	if !callResp.Ok {
-		log.Fatalf("Error in call:\n%+v\n\nRequest was:\n%+v", callResp, call)
-	}
-
-	var postResp client.GenericResponse
-	ExecuteOrDie(
-		client.PostMessageRequest{
-			ChannelId: getChannelOrDie(newChannelName()).Id,
-			Text:      "Join the Video Call",
-			Blocks: []client.Block{
-				client.Block{Type: "call", CallId: callResp.Call.Id},
-			},
-		},
-		&postResp)
-	if !postResp.Ok {
-		log.Fatalf("Error posting message:\n%+v", postResp)
+	runSteps(w, r, postCall)
+}
+
+// postCall runs the steps of PostCallHandler.
+func postCall(ctx context.Context, w io.Writer) error {
+	if !rotatesToday(w) {
+		return nil
+	}
+	name, err := newChannelName()
+	if err != nil {
+		return err
+	}
+	channel, err := getRotationChannel(ctx, name)
+	if err != nil {
+		return err
+	}
+	if channel == nil {
+		log.Printf("Can't find the channel #%s!", name)
+		return channelNotFound(name)
+	}
+
+	botUserId, err := getBotUserId(ctx)
+	if err != nil {
+		return err
+	}
+	posted, err := updatePostedCall(ctx, w, channel, botUserId)
+	if err != nil || posted {
+		return err
+	}
+	if err := postNewCall(ctx, w, channel); err != nil {
+		return err
 	}
+	// So it's not posted again when the call starts.
+	return scheduleAnnouncements(ctx, w, channel, false)
 }
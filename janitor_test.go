@@ -1,13 +1,20 @@
 package janitor
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/jaywhyzed/slackJanitor/client"
 	"github.com/jaywhyzed/slackJanitor/client/mocks"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestIndexHandler(t *testing.T) {
@@ -56,12 +63,32 @@ func getClient(t *testing.T) *mocks.MockClient {
 	defer mockCtrl.Finish()
 	mockClient := mocks.NewMockClient(mockCtrl)
 	slackClient = mockClient
+	onTuesday(t)
 	return mockClient
 }
 
+// expectUserInfo expects user to be looked up with users.info.
+func expectUserInfo(mockClient *mocks.MockClient, user client.User) *gomock.Call {
+	return mockClient.EXPECT().ExecuteContext(gomock.Any(),
+		/*req=*/ client.UsersInfoRequest{UserId: user.Id},
+		/*resp=*/ gomock.AssignableToTypeOf(&client.UserResponse{})).DoAndReturn(
+		func(ctx context.Context, req client.UsersInfoRequest, resp *client.UserResponse) (string, error) {
+			resp.Ok = true
+			resp.User = user
+			return "raw json", nil
+		}).Times(1)
+}
+
+// onTuesday sets the clock to a Tuesday morning, when the default cadence
+// has a channel, for the rest of the test.
+func onTuesday(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 10, 13, 8, 0, 0, 0, config.Location()) }
+	t.Cleanup(func() { now = time.Now })
+}
+
 func TestCreateChannelWithoutCronHeader(t *testing.T) {
 	mockClient := getClient(t)
-	mockClient.EXPECT().Execute(gomock.Any(), gomock.Any()).Return("", nil).Times(0)
+	mockClient.EXPECT().ExecuteContext(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(0)
 
 	req, err := http.NewRequest("GET", "/create_channel", nil)
 	if err != nil {
@@ -85,7 +112,7 @@ func TestCreateChannelWithoutCronHeader(t *testing.T) {
 
 func TestPostCallWithoutCronHeader(t *testing.T) {
 	mockClient := getClient(t)
-	mockClient.EXPECT().Execute(gomock.Any(), gomock.Any()).Return("", nil).Times(0)
+	mockClient.EXPECT().ExecuteContext(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil).Times(0)
 
 	req, err := http.NewRequest("GET", "/post_call", nil)
 	if err != nil {
@@ -109,15 +136,19 @@ func TestPostCallWithoutCronHeader(t *testing.T) {
 
 func TestCreateChannel(t *testing.T) {
 	mockClient := getClient(t)
+	// In the morning, before the call's reminder, see getClient.
+	setConfig(t, func(c *Config) { c.Reminders = []time.Duration{2 * time.Hour, time.Hour} })
+	announcements := callAnnouncements("newchannelid", todayCallStart(), "http://zoom", true)
+	announcements[2].Blocks = client.Blocks{client.CallBlock{CallId: "RNEW"}}
 
 	// Set the mocks.
 
 	gomock.InOrder(
 		// First, Create the channel.
-		mockClient.EXPECT().Execute(
-			/*req=*/ client.CreateChannelRequest{Name: newChannelName()},
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.CreateChannelRequest{Name: testChannelName(t, newChannelName)},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelResponse{})).DoAndReturn(
-			func(req client.CreateChannelRequest,
+			func(ctx context.Context, req client.CreateChannelRequest,
 				resp *client.ChannelResponse) (string, error) {
 				resp.Ok = true
 				resp.Channel.Id = "newchannelid"
@@ -125,34 +156,76 @@ func TestCreateChannel(t *testing.T) {
 				return "raw json haha", nil
 			}).Times(1),
 		// Set the Channel's Topic.
-		mockClient.EXPECT().Execute(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.ChannelSetTopicRequest{
 				ChannelId: "newchannelid", Topic: "Video Call: http://zoom"},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
-			func(req client.ChannelSetTopicRequest,
+			func(ctx context.Context, req client.ChannelSetTopicRequest,
+				resp *client.GenericResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		// Bookmark the video call, and remove a link that's no longer configured.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.AuthTestRequest{},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.AuthTestResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.AuthTestRequest,
+				resp *client.AuthTestResponse) (string, error) {
+				resp.Ok = true
+				resp.UserId = "UBOT"
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.BookmarksListRequest{ChannelId: "newchannelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.BookmarksListResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.BookmarksListRequest,
+				resp *client.BookmarksListResponse) (string, error) {
+				resp.Ok = true
+				resp.Bookmarks = []client.Bookmark{
+					{Id: "Bk1", Title: "Old rules", Link: "http://rules", LastUpdatedByUserId: "UBOT"},
+					{Id: "Bk2", Title: "Snacks", Link: "http://snacks", LastUpdatedByUserId: "123"},
+				}
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.BookmarksAddRequest{
+				ChannelId: "newchannelid", Title: "Video Call", Type: client.BookmarkLink, Link: "http://zoom"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.BookmarkResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.BookmarksAddRequest,
+				resp *client.BookmarkResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.BookmarksRemoveRequest{ChannelId: "newchannelid", BookmarkId: "Bk1"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.BookmarksRemoveRequest,
 				resp *client.GenericResponse) (string, error) {
 				resp.Ok = true
 				return "raw json", nil
 			}).Times(1),
 		// Get all the users.
-		mockClient.EXPECT().Execute(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.UsersListRequest{},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.UsersListResponse{})).DoAndReturn(
-			func(req client.UsersListRequest,
+			func(ctx context.Context, req client.UsersListRequest,
 				resp *client.UsersListResponse) (string, error) {
 				resp.Ok = true
 				resp.Members = []client.User{
 					client.User{Id: "123", Name: "User1", Deleted: false, IsBot: false},
 					client.User{Id: "1337", Name: "Bot1", Deleted: false, IsBot: true},
 					client.User{Id: "000", Name: "DeletedUser", Deleted: true, IsBot: false},
+					client.User{Id: client.SlackbotId, Name: "slackbot"},
+					client.User{Id: "222", Name: "Guest", IsRestricted: true, IsUltraRestricted: true},
+					client.User{Id: "333", Name: "App", IsAppUser: true},
 				}
 				resp.Metadata.NextCursor = "contToken"
 				return "raw json", nil
 			}).Times(1),
-		mockClient.EXPECT().Execute(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.UsersListRequest{Cursor: "contToken"},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.UsersListResponse{})).DoAndReturn(
-			func(req client.UsersListRequest,
+			func(ctx context.Context, req client.UsersListRequest,
 				resp *client.UsersListResponse) (string, error) {
 				resp.Ok = true
 				resp.Members = []client.User{
@@ -161,34 +234,125 @@ func TestCreateChannel(t *testing.T) {
 				}
 				return "raw json", nil
 			}).Times(1),
+		// Get the current members, only the creator.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationMembersRequest{ChannelId: "newchannelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ConversationMembersResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationMembersRequest,
+				resp *client.ConversationMembersResponse) (string, error) {
+				resp.Ok = true
+				resp.Members = []string{"1337"}
+				return "raw json", nil
+			}).Times(1),
 		// Invite the users.
-		mockClient.EXPECT().Execute(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.ConversationInvite{
 				ChannelId: "newchannelid",
 				Users:     []string{"123", "456", "789"},
+				Force:     true,
 			},
-			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelResponse{})).DoAndReturn(
-			func(req client.ConversationInvite,
-				resp *client.ChannelResponse) (string, error) {
+			/*resp=*/ gomock.AssignableToTypeOf(&client.InviteResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationInvite,
+				resp *client.InviteResponse) (string, error) {
 				resp.Ok = true
-				resp.Channel = client.Channel{Id: "newchannelid", Name: newChannelName()}
+				resp.Channel = client.Channel{Id: "newchannelid", Name: testChannelName(t, newChannelName)}
 				return "raw json", nil
 			}).Times(1),
-		// Post a welcome message.
-		mockClient.EXPECT().Execute(
-			/*req=*/ client.PostMessageRequest{
-				ChannelId: "newchannelid",
-				Text:      "Hello, welcome to today's channel.\nOur new video call link is http://zoom"},
+		// Look for an earlier welcome message, only finding someone else's.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationsHistoryRequest{ChannelId: "newchannelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.MessagesResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationsHistoryRequest,
+				resp *client.MessagesResponse) (string, error) {
+				resp.Ok = true
+				resp.Messages = []client.Message{{User: "123", Text: config.Welcome.Text}}
+				return "raw json", nil
+			}).Times(1),
+		// Post a welcome message, and pin it.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ welcomeMessage("newchannelid", "http://zoom"),
+			/*resp=*/ gomock.AssignableToTypeOf(&client.PostMessageResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.PostMessageRequest, resp *client.PostMessageResponse) (string, error) {
+				resp.Ok = true
+				resp.Channel = "newchannelid"
+				resp.Ts = "1791298800.000001"
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.PinsAddRequest{ChannelId: "newchannelid", Timestamp: "1791298800.000001"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.PinsAddRequest, resp *client.GenericResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		// The Call wasn't posted yet.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationsHistoryRequest{ChannelId: "newchannelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.MessagesResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationsHistoryRequest,
+				resp *client.MessagesResponse) (string, error) {
+				resp.Ok = true
+				resp.Messages = []client.Message{{User: "UBOT", Text: config.Welcome.Text}}
+				return "raw json", nil
+			}).Times(1),
+		// Replace the scheduled messages of an earlier run. The one at the
+		// same time that links the video call is kept, however Slack
+		// rewrote its text.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ScheduledMessagesListRequest{ChannelId: "newchannelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ScheduledMessagesListResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ScheduledMessagesListRequest,
+				resp *client.ScheduledMessagesListResponse) (string, error) {
+				resp.Ok = true
+				resp.ScheduledMessages = []client.ScheduledMessage{
+					{Id: "Q1", ChannelId: "newchannelid", PostAt: announcements[1].PostAt,
+						Text: "Reminder: game time starts soon. Video call: <http://zoom|zoom>"},
+					{Id: "Q2", ChannelId: "newchannelid", PostAt: announcements[1].PostAt,
+						Text: "Video call: <http://old>"},
+				}
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.DeleteScheduledMessageRequest{ChannelId: "newchannelid", ScheduledMessageId: "Q2"},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
-			func(req client.PostMessageRequest, resp *client.GenericResponse) (string, error) {
+			func(ctx context.Context, req client.DeleteScheduledMessageRequest,
+				resp *client.GenericResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ announcements[0],
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ScheduleMessageResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ScheduleMessageRequest,
+				resp *client.ScheduleMessageResponse) (string, error) {
+				resp.Ok = true
+				resp.ScheduledMessageId = "Q3"
+				return "raw json", nil
+			}).Times(1),
+		// Add the Call, to be posted when it starts.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ configuredCall(testChannelName(t, newChannelName)),
+			/*resp=*/ gomock.AssignableToTypeOf(&client.CallResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.Call, resp *client.CallResponse) (string, error) {
+				resp.Ok = true
+				resp.Call = req
+				resp.Call.Id = "RNEW"
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ announcements[2],
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ScheduleMessageResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ScheduleMessageRequest,
+				resp *client.ScheduleMessageResponse) (string, error) {
 				resp.Ok = true
+				resp.ScheduledMessageId = "Q4"
 				return "raw json", nil
 			}).Times(1),
 		// Find the old channel, list all the channels until we get the old one.
-		mockClient.EXPECT().Execute(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.ChannelListRequest{},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelListResponse{})).DoAndReturn(
-			func(req client.ChannelListRequest,
+			func(ctx context.Context, req client.ChannelListRequest,
 				resp *client.ChannelListResponse) (string, error) {
 				resp.Ok = true
 				resp.Channels = []client.Channel{
@@ -198,26 +362,63 @@ func TestCreateChannel(t *testing.T) {
 				resp.Metadata.NextCursor = "cursorX"
 				return "raw json", nil
 			}).Times(1),
-		mockClient.EXPECT().Execute(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.ChannelListRequest{Cursor: "cursorX"},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelListResponse{})).DoAndReturn(
-			func(req client.ChannelListRequest,
+			func(ctx context.Context, req client.ChannelListRequest,
 				resp *client.ChannelListResponse) (string, error) {
 				resp.Ok = true
 				resp.Channels = []client.Channel{
 					client.Channel{Id: "z", Name: "blah"},
-					client.Channel{Id: "oldchannelid", Name: oldChannelName()},
+					client.Channel{Id: "oldchannelid", Name: testChannelName(t, oldChannelName), Creator: "UBOT"},
 					client.Channel{Id: "a", Name: "foochannel"},
 				}
 				resp.Metadata.NextCursor = "cursorY"
 				return "raw json", nil
 			}).Times(1),
 		// We set a cursor above, but because the channel was found we don't expect
-		// another call. Archive the channel.
-		mockClient.EXPECT().Execute(
+		// another call.
+		// Summarize its history.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationsHistoryRequest{ChannelId: "oldchannelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.MessagesResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationsHistoryRequest,
+				resp *client.MessagesResponse) (string, error) {
+				resp.Ok = true
+				resp.Messages = []client.Message{
+					client.Message{User: "U1", Text: "gg", ReplyCount: 1, ReplyUsers: []string{"U2"}},
+					client.Message{User: "U3", Subtype: "channel_join", Text: "<@U3> has joined the channel"},
+					client.Message{User: "UBOT", BotId: "BBOT", Text: "Join the Video Call",
+						Blocks: client.Blocks{client.CallBlock{CallId: "R0LD"}}},
+				}
+				return "raw json", nil
+			}).Times(1),
+		// Show the people by name.
+		expectUserInfo(mockClient, client.User{Id: "U1", Name: "alice"}),
+		expectUserInfo(mockClient, client.User{Id: "U2", Name: "bob", Profile: client.UserProfile{DisplayName: "Bobby"}}),
+		// End its Call, found in the history.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.CallInfoRequest{Id: "R0LD"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.CallResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.CallInfoRequest,
+				resp *client.CallResponse) (string, error) {
+				resp.Ok = true
+				resp.Call = client.Call{Id: "R0LD", Users: []client.CallUser{{SlackId: "U1"}}}
+				return "raw json", nil
+			}).Times(1),
+		expectUserInfo(mockClient, client.User{Id: "U1", Name: "alice"}),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.CallEnd{Id: "R0LD"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.CallEnd, resp *client.GenericResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		// Archive the channel.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.ChannelArchiveRequest{ChannelId: "oldchannelid"},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
-			func(req client.ChannelArchiveRequest, resp *client.GenericResponse) (string, error) {
+			func(ctx context.Context, req client.ChannelArchiveRequest, resp *client.GenericResponse) (string, error) {
 				resp.Ok = true
 				return "raw json", nil
 			}).Times(1))
@@ -241,61 +442,384 @@ func TestCreateChannel(t *testing.T) {
 		)
 	}
 
-	t.Logf("Returned body:\n%v", rr.Body.String())
+	if body := rr.Body.String(); !strings.Contains(body, "had 1 messages and 1 replies from 2 people: alice, Bobby") {
+		t.Errorf("Expected a summary of the old channel, got body:\n%v", body)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Bookmarks: 1 added, 0 edited, 1 removed") {
+		t.Errorf("Expected the video call to be bookmarked, got body:\n%v", body)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Scheduled 2 announcements, kept 1, removed 1") {
+		t.Errorf("Expected the reminder and the Call to be scheduled, got body:\n%v", body)
+	}
 
 	t.Logf("Got client: %v", mockClient)
 }
 
+// An existing channel is fetched rather than created.
+func TestCreateChannelNameTaken(t *testing.T) {
+	mockClient := getClient(t)
+
+	gomock.InOrder(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.CreateChannelRequest{Name: testChannelName(t, newChannelName)},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.CreateChannelRequest,
+				resp *client.ChannelResponse) (string, error) {
+				resp.Error = "name_taken"
+				return "raw json", &client.SlackError{
+					Method: "conversations.create", Code: "name_taken"}
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ChannelListRequest{IncludeArchived: true},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelListResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ChannelListRequest,
+				resp *client.ChannelListResponse) (string, error) {
+				resp.Ok = true
+				resp.Channels = []client.Channel{
+					client.Channel{Id: "existingid", Name: testChannelName(t, newChannelName)},
+				}
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ChannelSetTopicRequest{
+				ChannelId: "existingid", Topic: "Video Call: http://zoom"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ChannelSetTopicRequest,
+				resp *client.GenericResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		// The video call is already bookmarked.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.AuthTestRequest{},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.AuthTestResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.AuthTestRequest,
+				resp *client.AuthTestResponse) (string, error) {
+				resp.Ok = true
+				resp.UserId = "UBOT"
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.BookmarksListRequest{ChannelId: "existingid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.BookmarksListResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.BookmarksListRequest,
+				resp *client.BookmarksListResponse) (string, error) {
+				resp.Ok = true
+				resp.Bookmarks = []client.Bookmark{
+					{Id: "Bk1", Title: "Video Call", Link: "http://zoom", LastUpdatedByUserId: "UBOT"},
+				}
+				return "raw json", nil
+			}).Times(1))
+
+	req, err := http.NewRequest("GET", "/create_channel?create_only", nil)
+	req.Header.Add("X-Appengine-Cron", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(CreateChannelHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf(
+			"unexpected status: got (%v) want (%v)",
+			status,
+			http.StatusOK,
+		)
+	}
+
+	t.Logf("Returned body:\n%v", rr.Body.String())
+}
+
+// A cancelled request stops the run after the in-flight call.
+func TestCreateChannelCancelled(t *testing.T) {
+	mockClient := getClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockClient.EXPECT().ExecuteContext(ctx,
+		/*req=*/ client.CreateChannelRequest{Name: testChannelName(t, newChannelName)},
+		/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelResponse{})).DoAndReturn(
+		func(ctx context.Context, req client.CreateChannelRequest,
+			resp *client.ChannelResponse) (string, error) {
+			cancel()
+			return "", ctx.Err()
+		}).Times(1)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "/create_channel", nil)
+	req.Header.Add("X-Appengine-Cron", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(CreateChannelHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status: got (%v) want (%v)", status, http.StatusServiceUnavailable)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "Run cancelled") {
+		t.Errorf("Expected the run to be cancelled, got body:\n%v", body)
+	}
+}
+
+// Slack refusing a step fails the run with a 502 saying why.
+func TestCreateChannelSlackError(t *testing.T) {
+	mockClient := getClient(t)
+
+	mockClient.EXPECT().ExecuteContext(gomock.Any(),
+		/*req=*/ client.CreateChannelRequest{Name: testChannelName(t, newChannelName)},
+		/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelResponse{})).DoAndReturn(
+		func(ctx context.Context, req client.CreateChannelRequest,
+			resp *client.ChannelResponse) (string, error) {
+			resp.Error = "restricted_action"
+			return "raw json", &client.SlackError{
+				Method: "conversations.create", Code: "restricted_action"}
+		}).Times(1)
+
+	req, err := http.NewRequest("GET", "/create_channel", nil)
+	req.Header.Add("X-Appengine-Cron", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(CreateChannelHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadGateway {
+		t.Errorf("unexpected status: got (%v) want (%v)", status, http.StatusBadGateway)
+	}
+	var failure Failure
+	if err := json.Unmarshal(rr.Body.Bytes(), &failure); err != nil {
+		t.Fatalf("Can't decode the body:\n%v\n%v", rr.Body.String(), err)
+	}
+	if failure.Ok || failure.Error != "slack_error" ||
+		failure.SlackError != "restricted_action" || failure.Method != "conversations.create" {
+		t.Errorf("Unexpected failure: %+v", failure)
+	}
+	if !strings.Contains(failure.Output, "Hello, World!") {
+		t.Errorf("Expected the output so far, got: %q", failure.Output)
+	}
+}
+
+// Without a token, the run fails with a 500 before calling Slack.
+func TestCreateChannelMissingToken(t *testing.T) {
+	slackClient = nil
+	onTuesday(t)
+	token, hasToken := os.LookupEnv("SLACK_BOT_USER_TOKEN")
+	os.Unsetenv("SLACK_BOT_USER_TOKEN")
+	defer func() {
+		if hasToken {
+			os.Setenv("SLACK_BOT_USER_TOKEN", token)
+		}
+	}()
+
+	req, err := http.NewRequest("GET", "/create_channel", nil)
+	req.Header.Add("X-Appengine-Cron", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(CreateChannelHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("unexpected status: got (%v) want (%v)", status, http.StatusInternalServerError)
+	}
+	if body := rr.Body.String(); !strings.Contains(body, `"error":"misconfigured"`) {
+		t.Errorf("Expected a misconfigured failure, got body:\n%v", body)
+	}
+}
+
 func TestPostCall(t *testing.T) {
 	mockClient := getClient(t)
 
 	// Set the mocks.
 
 	gomock.InOrder(
+		// Get the channel.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ChannelListRequest{},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelListResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ChannelListRequest,
+				resp *client.ChannelListResponse) (string, error) {
+				resp.Ok = true
+				resp.Channels = []client.Channel{
+					client.Channel{Id: "channelid", Name: testChannelName(t, newChannelName)},
+					client.Channel{Id: "foo", Name: "covid"},
+				}
+				return "raw json", nil
+			}).Times(1),
+		// No call was posted yet.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.AuthTestRequest{},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.AuthTestResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.AuthTestRequest,
+				resp *client.AuthTestResponse) (string, error) {
+				resp.Ok = true
+				resp.UserId = "UBOT"
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationsHistoryRequest{ChannelId: "channelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.MessagesResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationsHistoryRequest,
+				resp *client.MessagesResponse) (string, error) {
+				resp.Ok = true
+				resp.Messages = []client.Message{
+					client.Message{User: "UBOT", BotId: "BBOT", Text: "Hello, welcome to today's channel."},
+					// Someone else's call doesn't count.
+					client.Message{User: "U1", Blocks: client.Blocks{client.CallBlock{CallId: "RU1"}}},
+				}
+				return "raw json", nil
+			}).Times(1),
 		// Create the Call object.
-		mockClient.EXPECT().Execute(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.Call{
-				ExternalUniqueId:  newChannelName(),
+				ExternalUniqueId:  testChannelName(t, newChannelName),
 				JoinUrl:           "http://zoom",
 				ExternalDisplayId: "123456",
 				Title:             "Game Time!",
-				StartTimeUnix:     todayAtSixThirty().Unix(),
+				StartTimeUnix:     todayCallStart().Unix(),
 			},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.CallResponse{})).DoAndReturn(
-			func(req client.Call,
+			func(ctx context.Context, req client.Call,
 				resp *client.CallResponse) (string, error) {
 				resp.Ok = true
 				resp.Call = req
 				resp.Call.Id = "987654"
 				return "raw json haha", nil
 			}).Times(1),
-		// Get the channel.
-		mockClient.EXPECT().Execute(
+		// Post it now.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.PostMessageRequest{
+				ChannelId: "channelid",
+				Text:      "Join the Video Call: <http://zoom>",
+				Blocks:    client.Blocks{client.CallBlock{CallId: "987654"}},
+			},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.PostMessageResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.PostMessageRequest, resp *client.PostMessageResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		// Unschedule the Call create_channel scheduled, keeping the reminder.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ScheduledMessagesListRequest{ChannelId: "channelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ScheduledMessagesListResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ScheduledMessagesListRequest,
+				resp *client.ScheduledMessagesListResponse) (string, error) {
+				resp.Ok = true
+				resp.ScheduledMessages = []client.ScheduledMessage{
+					{Id: "Q1", ChannelId: "channelid", PostAt: todayCallStart().Add(-time.Hour).Unix(),
+						Text: "Reminder: game time starts at 6:30 PM. Video call: <http://zoom>"},
+					{Id: "Q2", ChannelId: "channelid", PostAt: todayCallStart().Unix(),
+						Text: "Join the Video Call: <http://zoom>"},
+				}
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.DeleteScheduledMessageRequest{ChannelId: "channelid", ScheduledMessageId: "Q2"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.DeleteScheduledMessageRequest,
+				resp *client.GenericResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1))
+
+	// set the header via
+	req, err := http.NewRequest("GET", "/post_call", nil)
+	req.Header.Add("X-Appengine-Cron", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(PostCallHandler)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf(
+			"unexpected status: got (%v) want (%v)",
+			status,
+			http.StatusOK,
+		)
+	}
+
+	if body := rr.Body.String(); !strings.Contains(body, "Scheduled 0 announcements, kept 1, removed 1") {
+		t.Errorf("Expected the scheduled Call to be removed, got body:\n%v", body)
+	}
+	t.Logf("Returned body:\n%v", rr.Body.String())
+}
+
+// A re-run updates the posted Call rather than posting another one.
+func TestPostCallRerun(t *testing.T) {
+	mockClient := getClient(t)
+
+	gomock.InOrder(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
 			/*req=*/ client.ChannelListRequest{},
 			/*resp=*/ gomock.AssignableToTypeOf(&client.ChannelListResponse{})).DoAndReturn(
-			func(req client.ChannelListRequest,
+			func(ctx context.Context, req client.ChannelListRequest,
 				resp *client.ChannelListResponse) (string, error) {
 				resp.Ok = true
 				resp.Channels = []client.Channel{
-					client.Channel{Id: "channelid", Name: newChannelName()},
-					client.Channel{Id: "foo", Name: "covid"},
+					client.Channel{Id: "channelid", Name: testChannelName(t, newChannelName)},
 				}
 				return "raw json", nil
 			}).Times(1),
-		// Post a reminder.
-		mockClient.EXPECT().Execute(
-			/*req=*/ client.PostMessageRequest{
-				ChannelId: "channelid",
-				Text:      "Join the Video Call",
-				Blocks:    []client.Block{client.Block{Type: "call", CallId: "987654"}},
-			},
-			/*resp=*/ gomock.AssignableToTypeOf(&client.GenericResponse{})).DoAndReturn(
-			func(req client.PostMessageRequest, resp *client.GenericResponse) (string, error) {
+		// The posted call block has the Call.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.AuthTestRequest{},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.AuthTestResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.AuthTestRequest,
+				resp *client.AuthTestResponse) (string, error) {
+				resp.Ok = true
+				resp.UserId = "UBOT"
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationsHistoryRequest{ChannelId: "channelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.MessagesResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationsHistoryRequest,
+				resp *client.MessagesResponse) (string, error) {
+				resp.Ok = true
+				resp.Messages = []client.Message{
+					client.Message{User: "UBOT", BotId: "BBOT", Text: "Join the Video Call",
+						Blocks: client.Blocks{client.CallBlock{CallId: "987654"}}},
+				}
+				// Not fetched, the Call was already found.
+				resp.Metadata.NextCursor = "cursorX"
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.CallInfoRequest{Id: "987654"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.CallResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.CallInfoRequest,
+				resp *client.CallResponse) (string, error) {
+				resp.Ok = true
+				resp.Call = client.Call{
+					Id:      "987654",
+					JoinUrl: "http://zoom",
+					Title:   "Old title",
+					Users:   []client.CallUser{{SlackId: "U1"}, {DisplayName: "Guest"}},
+				}
+				return "raw json", nil
+			}).Times(1),
+		expectUserInfo(mockClient, client.User{Id: "U1", Name: "alice", RealName: "Alice Liddell"}),
+		// Only the title changed.
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.CallUpdateRequest{Id: "987654", Title: "Game Time!"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.CallResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.CallUpdateRequest,
+				resp *client.CallResponse) (string, error) {
 				resp.Ok = true
 				return "raw json", nil
 			}).Times(1))
 
-	// set the header via
 	req, err := http.NewRequest("GET", "/post_call", nil)
 	req.Header.Add("X-Appengine-Cron", "true")
 	if err != nil {
@@ -313,14 +837,160 @@ func TestPostCall(t *testing.T) {
 			http.StatusOK,
 		)
 	}
+	if body := rr.Body.String(); !strings.Contains(body, "participants (2): Alice Liddell, Guest") {
+		t.Errorf("Expected the participants, got body:\n%v", body)
+	}
+}
 
-	t.Logf("Returned body:\n%v", rr.Body.String())
+// Only missing users are invited, and per-user failures are reported.
+func TestInviteUsers(t *testing.T) {
+	mockClient := getClient(t)
+
+	gomock.InOrder(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationMembersRequest{ChannelId: "channelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ConversationMembersResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationMembersRequest,
+				resp *client.ConversationMembersResponse) (string, error) {
+				resp.Ok = true
+				resp.Members = []string{"UBOT", "U1"}
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationInvite{
+				ChannelId: "channelid",
+				Users:     []string{"U2", "U3", "U4"},
+				Force:     true,
+			},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.InviteResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationInvite,
+				resp *client.InviteResponse) (string, error) {
+				resp.Ok = true
+				resp.Errors = []client.InviteError{
+					{User: "U3", Error: "user_is_restricted"},
+					{User: "U4", Error: "already_in_channel"},
+				}
+				return "raw json", nil
+			}).Times(1))
+
+	users := []client.User{{Id: "U1"}, {Id: "U2"}, {Id: "U3"}, {Id: "U4"}}
+	result, err := inviteUsers(context.Background(), "channelid", users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := inviteResult{
+		Invited:        []string{"U2"},
+		AlreadyPresent: []string{"U1", "U4"},
+		Failed:         []client.InviteError{{User: "U3", Error: "user_is_restricted"}},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected:\n%+v\nbut got:\n%+v", expected, result)
+	}
+	if summary := result.String(); !strings.Contains(summary, "U3: user_is_restricted") {
+		t.Errorf("Summary doesn't show the failure:\n%s", summary)
+	}
+}
+
+// Invites are split into batches, and their results combined.
+func TestInviteUsersInBatches(t *testing.T) {
+	mockClient := getClient(t)
+	inviteBatchSize = 2
+	defer func() { inviteBatchSize = client.MaxInviteUsers }()
+
+	gomock.InOrder(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationMembersRequest{ChannelId: "channelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ConversationMembersResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationMembersRequest,
+				resp *client.ConversationMembersResponse) (string, error) {
+				resp.Ok = true
+				resp.Members = []string{"UBOT"}
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationInvite{ChannelId: "channelid", Users: []string{"U1", "U2"}, Force: true},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.InviteResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationInvite,
+				resp *client.InviteResponse) (string, error) {
+				resp.Ok = true
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationInvite{ChannelId: "channelid", Users: []string{"U3"}, Force: true},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.InviteResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationInvite,
+				resp *client.InviteResponse) (string, error) {
+				resp.Error = "user_is_restricted"
+				resp.Errors = []client.InviteError{{User: "U3", Error: "user_is_restricted"}}
+				return "raw json", &client.SlackError{
+					Method: "conversations.invite", Code: "user_is_restricted"}
+			}).Times(1))
+
+	users := []client.User{{Id: "U1"}, {Id: "U2"}, {Id: "U3"}}
+	result, err := inviteUsers(context.Background(), "channelid", users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := inviteResult{
+		Invited: []string{"U1", "U2"},
+		Failed:  []client.InviteError{{User: "U3", Error: "user_is_restricted"}},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected:\n%+v\nbut got:\n%+v", expected, result)
+	}
+}
+
+// Slack refusing the whole request fails the run, rather than every user.
+func TestInviteUsersArchived(t *testing.T) {
+	mockClient := getClient(t)
+
+	gomock.InOrder(
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationMembersRequest{ChannelId: "channelid"},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.ConversationMembersResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationMembersRequest,
+				resp *client.ConversationMembersResponse) (string, error) {
+				resp.Ok = true
+				resp.Members = []string{"UBOT"}
+				return "raw json", nil
+			}).Times(1),
+		mockClient.EXPECT().ExecuteContext(gomock.Any(),
+			/*req=*/ client.ConversationInvite{ChannelId: "channelid", Users: []string{"U1", "U2"}, Force: true},
+			/*resp=*/ gomock.AssignableToTypeOf(&client.InviteResponse{})).DoAndReturn(
+			func(ctx context.Context, req client.ConversationInvite,
+				resp *client.InviteResponse) (string, error) {
+				resp.Error = "is_archived"
+				return "raw json", &client.SlackError{
+					Method: "conversations.invite", Code: "is_archived"}
+			}).Times(1))
+
+	users := []client.User{{Id: "U1"}, {Id: "U2"}}
+	_, err := inviteUsers(context.Background(), "channelid", users)
+	var slackErr *client.SlackError
+	if !errors.As(err, &slackErr) || slackErr.Code != "is_archived" {
+		t.Errorf("Expected is_archived, got %v", err)
+	}
+}
+
+// testChannelName returns the name, failing the test if it can't be made.
+func testChannelName(t *testing.T, name func() (string, error)) string {
+	n, err := name()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
 }
 
 func TestMain(m *testing.M) {
 	// call flag.Parse() here if TestMain uses flags
 	os.Setenv("VC_URL", "http://zoom")
 	os.Setenv("VC_CALL_ID", "123456")
+	// The config was loaded before VC_URL was set.
+	if config, configErr = loadConfig(configPath()); configErr != nil {
+		log.Fatal(configErr)
+	}
 	requireCron = true
 	os.Exit(m.Run())
 }
@@ -0,0 +1,113 @@
+package janitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ChannelNameConfig is how the rotation's channels are named: the prefix,
+// then Format, then the suffix. Format is a text/template over
+// channelNameData, e.g. "{{.Rotation}}-{{.ISOYear}}-w{{.Week}}".
+type ChannelNameConfig struct {
+	Prefix string `yaml:"prefix"`
+	Format string `yaml:"format"`
+	// DateLayout formats {{.Date}}, see time.Layout.
+	DateLayout string `yaml:"date_layout"`
+	Suffix     string `yaml:"suffix"`
+
+	tmpl *template.Template
+}
+
+// channelNameData is what channel name formats can use.
+type channelNameData struct {
+	// Rotation is the rotation's name.
+	Rotation string
+	// Date is the channel's date, formatted with DateLayout.
+	Date string
+	Year int
+	// ISOYear and Week are the channel's ISO 8601 week, with Week zero
+	// padded to two digits.
+	ISOYear int
+	Week    string
+}
+
+// maxChannelNameLength is the longest name Slack accepts.
+const maxChannelNameLength = 80
+
+var channelNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// validChannelName checks name against Slack's rules: at most 80
+// lowercase letters, numbers, hyphens and underscores.
+func validChannelName(name string) error {
+	switch {
+	case len(name) == 0:
+		return fmt.Errorf("channel name is empty")
+	case len(name) > maxChannelNameLength:
+		return fmt.Errorf("channel name %q is longer than %d characters", name, maxChannelNameLength)
+	case !channelNamePattern.MatchString(name):
+		return fmt.Errorf("channel name %q can only have lowercase letters, numbers, hyphens and underscores", name)
+	}
+	return nil
+}
+
+// compile parses Format.
+func (c *ChannelNameConfig) compile() error {
+	tmpl, err := template.New("channel_name").Option("missingkey=error").Parse(c.Format)
+	if err != nil {
+		return fmt.Errorf("channel_name.format: %w", err)
+	}
+	c.tmpl = tmpl
+	return nil
+}
+
+// name is the channel name for the date of t, in loc.
+func (c *ChannelNameConfig) name(rotation string, t time.Time, loc *time.Location) (string, error) {
+	t = t.In(loc)
+	isoYear, week := t.ISOWeek()
+	var b strings.Builder
+	b.WriteString(c.Prefix)
+	err := c.tmpl.Execute(&b, channelNameData{
+		Rotation: rotation,
+		Date:     t.Format(c.DateLayout),
+		Year:     t.Year(),
+		ISOYear:  isoYear,
+		Week:     fmt.Sprintf("%02d", week),
+	})
+	if err != nil {
+		return "", fmt.Errorf("channel_name.format: %w", err)
+	}
+	b.WriteString(c.Suffix)
+	return b.String(), nil
+}
+
+// validate checks the names of a year's worth of channels, so e.g. month
+// names in DateLayout are caught at startup.
+func (c *ChannelNameConfig) validate(rotation string, fallbackSuffix string, loc *time.Location) error {
+	if err := c.compile(); err != nil {
+		return err
+	}
+	day := time.Date(2026, time.January, 1, 12, 0, 0, 0, loc)
+	for i := 0; i < 366; i++ {
+		name, err := c.name(rotation, day.AddDate(0, 0, i), loc)
+		if err != nil {
+			return err
+		}
+		if err := validChannelName(name); err != nil {
+			return err
+		}
+		if err := validChannelName(name + fallbackSuffix); err != nil {
+			return fmt.Errorf("fallback_suffix: %w", err)
+		}
+	}
+	return nil
+}
+
+// ChannelName is the name of the rotation's channel for the date of t.
+// validate checks the format, but executing it can still fail, e.g. on a
+// date it didn't check.
+func (c *Config) ChannelName(t time.Time) (string, error) {
+	return c.Naming.name(c.Rotation, t, c.location)
+}
@@ -0,0 +1,129 @@
+package janitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// errMissingToken is returned by every Slack call when
+// SLACK_BOT_USER_TOKEN isn't set.
+var errMissingToken = errors.New("Missing token! Set SLACK_BOT_USER_TOKEN")
+
+// runError is a failure with its own status and error code, rather than
+// one derived from the underlying error.
+type runError struct {
+	Status int
+	Code   string
+	Err    error
+}
+
+func (e *runError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *runError) Unwrap() error {
+	return e.Err
+}
+
+// channelNotFound is returned when the channel a run works on doesn't exist.
+func channelNotFound(name string) error {
+	return &runError{
+		Status: http.StatusNotFound,
+		Code:   "channel_not_found",
+		Err:    fmt.Errorf("Can't find the channel #%s", name),
+	}
+}
+
+// Failure is the JSON body handlers answer with when a run fails.
+type Failure struct {
+	Ok bool `json:"ok"`
+	// Error says what failed:
+	//  - "slack_error": Slack refused a request, see SlackError and Method.
+	//  - "rate_limited": Slack kept rate limiting a request.
+	//  - "slack_unavailable": a request to Slack failed, e.g. a timeout or
+	//    an HTTP 5xx.
+	//  - "canceled": the run was cancelled or hit its deadline.
+	//  - "misconfigured": e.g. the token is missing or the config is
+	//    invalid.
+	//  - "channel_not_found": the channel to work on doesn't exist.
+	Error string `json:"error"`
+	// SlackError is Slack's error code, e.g. "channel_not_found".
+	SlackError string `json:"slack_error,omitempty"`
+	// Method is the Slack API method that failed.
+	Method  string `json:"method,omitempty"`
+	Message string `json:"message"`
+	// Output is what the run wrote before failing.
+	Output string `json:"output,omitempty"`
+}
+
+// failure describes err, and returns the HTTP status to answer with.
+func failure(ctx context.Context, err error) (int, Failure) {
+	f := Failure{Message: err.Error()}
+	var runErr *runError
+	var slackErr *client.SlackError
+	switch {
+	case errors.As(err, &runErr):
+		f.Error = runErr.Code
+		return runErr.Status, f
+	case ctx.Err() != nil || errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded):
+		f.Error = "canceled"
+		f.Message = "Run cancelled: " + err.Error()
+		return http.StatusServiceUnavailable, f
+	case client.IsRateLimited(err):
+		f.Error = "rate_limited"
+		return http.StatusServiceUnavailable, f
+	case errors.As(err, &slackErr):
+		f.Error = "slack_error"
+		f.SlackError = slackErr.Code
+		f.Method = slackErr.Method
+		return http.StatusBadGateway, f
+	case errors.Is(err, errMissingToken):
+		f.Error = "misconfigured"
+		return http.StatusInternalServerError, f
+	}
+	f.Error = "slack_unavailable"
+	return http.StatusBadGateway, f
+}
+
+// runSteps runs a handler's steps, buffering what they write. If they
+// succeed, that's the response. Otherwise the handler answers with an error
+// status and a JSON Failure, so cron retries and monitoring can tell what
+// went wrong.
+func runSteps(w http.ResponseWriter, r *http.Request,
+	steps func(ctx context.Context, w io.Writer) error) {
+	ctx := r.Context()
+	var out bytes.Buffer
+	var err error
+	if configErr != nil {
+		err = misconfigured(configErr)
+	} else {
+		err = steps(ctx, &out)
+	}
+	if err == nil {
+		w.Write(out.Bytes())
+		return
+	}
+
+	status, f := failure(ctx, err)
+	f.Output = out.String()
+	log.Printf("Run failed with %d %s: %v", status, f.Error, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(f)
+}
+
+// isSlackError reports whether err is Slack refusing a request, which some
+// steps tolerate, rather than a failure to make it.
+func isSlackError(err error) bool {
+	var slackErr *client.SlackError
+	return errors.As(err, &slackErr)
+}
@@ -0,0 +1,143 @@
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// callAnnouncements are the messages scheduled in the channel: a reminder
+// before the call for each of the configured reminders and, if withCall,
+// the message posting the Call when it starts. That one gets its Call block
+// when it's scheduled, since that adds the Call.
+func callAnnouncements(channelId string, start time.Time, vcUrl string, withCall bool) []client.ScheduleMessageRequest {
+	var messages []client.ScheduleMessageRequest
+	for _, reminder := range config.Reminders {
+		messages = append(messages, client.ScheduleMessageRequest{
+			ChannelId: channelId,
+			Text: fmt.Sprintf("Reminder: game time starts at %s. Video call: %s",
+				start.Format("3:04 PM"), client.FormatLink(vcUrl)),
+			PostAt: start.Add(-reminder).Unix(),
+		})
+	}
+	if withCall {
+		messages = append(messages, client.ScheduleMessageRequest{
+			ChannelId: channelId,
+			Text:      callText(vcUrl),
+			PostAt:    start.Unix(),
+		})
+	}
+	return messages
+}
+
+// linksTo returns whether a message's text links to url. Slack rewrites
+// the text of scheduled messages, e.g. escaping it, but keeps the links
+// the janitor formatted.
+func linksTo(text string, url string) bool {
+	for _, link := range client.TextLinks(text) {
+		if link == url {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleAnnouncements schedules the call's announcements in the channel,
+// with the Call if withCall. On re-runs, the messages already scheduled are
+// kept if they're at the same time and link the configured video call, and
+// replaced otherwise. Reminders that are already due are skipped, since
+// Slack can't schedule messages in the past, while a Call that's due is
+// posted right away.
+func scheduleAnnouncements(ctx context.Context, w io.Writer, channel *client.Channel, withCall bool) error {
+	start := todayCallStart()
+	vcUrl := config.Call.Url
+	announcements := callAnnouncements(channel.Id, start, vcUrl, withCall)
+
+	wanted := map[int64]bool{}
+	for _, announcement := range announcements {
+		wanted[announcement.PostAt] = true
+	}
+
+	// Only lists the messages the janitor scheduled.
+	scheduled := map[int64]bool{}
+	var stale []client.ScheduledMessage
+	var list_resp client.ScheduledMessagesListResponse
+	err := Paginate(ctx, client.ScheduledMessagesListRequest{ChannelId: channel.Id},
+		&list_resp, func() error {
+			for _, msg := range list_resp.ScheduledMessages {
+				if wanted[msg.PostAt] && !scheduled[msg.PostAt] && linksTo(msg.Text, vcUrl) {
+					scheduled[msg.PostAt] = true
+				} else {
+					stale = append(stale, msg)
+				}
+			}
+			return nil
+		})
+	if isSlackError(err) {
+		// Scheduling anyway could duplicate the announcements.
+		log.Printf("Can't list scheduled messages, not scheduling any:\n%v", err)
+		fmt.Fprintf(w, "Couldn't schedule the announcements\n")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// A replaced Call announcement leaves its Call unposted, which is
+	// harmless.
+	for _, msg := range stale {
+		log.Printf("Deleting scheduled message %s", msg.Id)
+		var delete_resp client.GenericResponse
+		_, err := Execute(ctx, client.DeleteScheduledMessageRequest{
+			ChannelId:          channel.Id,
+			ScheduledMessageId: msg.Id,
+		}, &delete_resp)
+		if isSlackError(err) {
+			log.Printf("Deleting scheduled message %s failed, ignoring:\n%v", msg.Id, err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	kept, added := 0, 0
+	for _, announcement := range announcements {
+		if scheduled[announcement.PostAt] {
+			kept++
+			continue
+		}
+		isCall := announcement.PostAt == start.Unix()
+		if postAt := time.Unix(announcement.PostAt, 0); !postAt.After(now()) {
+			if isCall {
+				// e.g. the channel was created after the call started.
+				if err := postNewCall(ctx, w, channel); err != nil {
+					return err
+				}
+				continue
+			}
+			log.Printf("Not scheduling a message for %v, it's already past", postAt)
+			continue
+		}
+		if isCall {
+			callId, err := addCall(ctx, w, channel.Name)
+			if err != nil {
+				return err
+			}
+			announcement.Blocks = client.Blocks{client.CallBlock{CallId: callId}}
+		}
+		var schedule_resp client.ScheduleMessageResponse
+		_, err := Execute(ctx, announcement, &schedule_resp)
+		if isSlackError(err) {
+			log.Printf("Scheduling a message failed, ignoring:\n%v", err)
+			continue
+		} else if err != nil {
+			return err
+		}
+		added++
+	}
+	fmt.Fprintf(w, "Scheduled %d announcements, kept %d, removed %d\n",
+		added, kept, len(stale))
+	return nil
+}
@@ -0,0 +1,146 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaywhyzed/slackJanitor/client"
+)
+
+// RetentionConfig is how many of the rotation's channels stay open.
+type RetentionConfig struct {
+	// Keep is how many of the newest channels the janitor created stay
+	// unarchived, including today's. Each run archives the rest. 0 only
+	// archives the previous channel on the cadence.
+	Keep int `yaml:"keep"`
+}
+
+// sweepDays is how far back channel names are recognized.
+const sweepDays = 3 * 366
+
+// datedChannel is a channel named by the rotation's scheme, and the day it
+// was named for.
+type datedChannel struct {
+	client.Channel
+	Day time.Time
+}
+
+// listRotationChannels lists the unarchived channels named by the
+// rotation's scheme for a day in the last sweepDays, including fallback
+// names, newest first.
+func listRotationChannels(ctx context.Context) ([]datedChannel, error) {
+	today := now().In(config.Location())
+	days := map[string]time.Time{}
+	// Oldest first, so days sharing a name, e.g. a week's, get the latest.
+	for i := sweepDays; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		name, err := config.ChannelName(day)
+		if err != nil {
+			return nil, misconfigured(err)
+		}
+		days[name] = day
+		days[fallbackChannelName(name)] = day
+	}
+
+	var channels []datedChannel
+	channels_resp := client.ChannelListResponse{}
+	err := Paginate(ctx, rotationChannelList(), &channels_resp, func() error {
+		for _, channel := range channels_resp.Channels {
+			if day, found := days[channel.Name]; found {
+				channels = append(channels, datedChannel{Channel: channel, Day: day})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error listing the rotation's channels: %w", err)
+	}
+
+	sort.SliceStable(channels, func(i, j int) bool {
+		if !channels[i].Day.Equal(channels[j].Day) {
+			return channels[i].Day.After(channels[j].Day)
+		}
+		return channels[i].Created > channels[j].Created
+	})
+	return channels, nil
+}
+
+// sweepChannels archives all but the newest config.Retention.Keep channels
+// the janitor created, so channels missed by skipped or failed runs don't
+// stay open. Channels it didn't create are left alone. Writes what it
+// archived and skipped.
+func sweepChannels(ctx context.Context, w io.Writer, botUserId string) error {
+	channels, err := listRotationChannels(ctx)
+	if err != nil {
+		return err
+	}
+
+	var kept, archived, skipped []string
+	for _, channel := range channels {
+		channel := channel
+		if channel.Creator == botUserId && len(kept) < config.Retention.Keep {
+			kept = append(kept, "#"+channel.Name)
+			continue
+		}
+		reason, err := retireChannel(ctx, w, &channel.Channel, botUserId)
+		if err != nil {
+			return err
+		}
+		if len(reason) > 0 {
+			skipped = append(skipped, fmt.Sprintf("#%s (%s)", channel.Name, reason))
+		} else {
+			archived = append(archived, "#"+channel.Name)
+		}
+	}
+
+	log.Printf("Swept %d channels: kept %v, archived %v, skipped %v",
+		len(channels), kept, archived, skipped)
+	fmt.Fprintf(w, "Swept %d channels: kept %d, archived %d, skipped %d\n",
+		len(channels), len(kept), len(archived), len(skipped))
+	if len(archived) > 0 {
+		fmt.Fprintf(w, "Archived: %s\n", strings.Join(archived, ", "))
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(w, "Skipped: %s\n", strings.Join(skipped, ", "))
+	}
+	return nil
+}
+
+// retireChannel summarizes the channel, ends its Call and archives it, if
+// the janitor created it. Returns why it wasn't archived, or "".
+func retireChannel(ctx context.Context, w io.Writer, channel *client.Channel, botUserId string) (string, error) {
+	if channel.Creator != botUserId {
+		// e.g. someone made a channel with a name like ours.
+		log.Printf("#%s was created by %s, not %s, leaving it alone",
+			channel.Name, channel.Creator, botUserId)
+		fmt.Fprintf(w, "Not archiving #%s, it wasn't created by the janitor\n", channel.Name)
+		return "not created by the janitor", nil
+	}
+
+	callId, err := summarizeChannel(ctx, w, channel, botUserId)
+	if err != nil {
+		return "", err
+	}
+	if err := endChannelCall(ctx, w, channel, callId); err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(w, "Attempting to archive #%s.\n", channel.Name)
+	archive_resp := client.GenericResponse{}
+	_, err = Execute(ctx, client.ChannelArchiveRequest{ChannelId: channel.Id}, &archive_resp)
+	var slackErr *client.SlackError
+	if errors.As(err, &slackErr) {
+		log.Printf("Archive failed, ignoring:\n%v", err)
+		return slackErr.Code, nil
+	} else if err != nil {
+		return "", err
+	}
+	log.Printf("Archive done.")
+	return "", nil
+}